@@ -0,0 +1,89 @@
+package main
+
+import (
+  "math"
+  "math/big"
+)
+
+// Arithmetic selects what integer arithmetic does when a result does not
+// fit in 64 bits.
+type Arithmetic string
+
+const (
+  // ARITHMETIC_BIG continues overflowing results as arbitrary precision
+  // integers. It is the default.
+  ARITHMETIC_BIG Arithmetic = "big"
+
+  // ARITHMETIC_CHECKED reports overflow as an error.
+  ARITHMETIC_CHECKED Arithmetic = "checked"
+
+  // ARITHMETIC_WRAPPING wraps around on overflow, as two's complement
+  // arithmetic does.
+  ARITHMETIC_WRAPPING Arithmetic = "wrapping"
+)
+
+// MAX_INTEGER_BITS bounds the size of arbitrary precision integers, so that
+// a result like `2 ** (2 ** 40)` is reported as an error rather than
+// exhausting memory.
+const MAX_INTEGER_BITS = 1 << 22
+
+var maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+
+// wrapInteger returns the low 64 bits of value as a two's complement
+// integer, which is what wrapping arithmetic would have produced.
+func wrapInteger(value *big.Int) int64 {
+  return int64(new(big.Int).And(value, maxUint64).Uint64())
+}
+
+// integerArithmetic applies one of the operators `+`, `-`, `*`, `/`, `%`,
+// `**` or `<<` to x and y, returning the wrapped result and whether it
+// overflowed. The divisor must not be zero, and neither the exponent nor
+// the shift count may be negative.
+func integerArithmetic(operator string, x, y int64) (int64, bool) {
+  switch operator {
+  case "+":
+    result := x + y
+    return result, (x^result)&(y^result) < 0
+  case "-":
+    result := x - y
+    return result, (x^y)&(x^result) < 0
+  case "*":
+    result := x * y
+    overflow := x != 0 && (result/x != y || x == -1 && y == math.MinInt64)
+    return result, overflow
+  case "/":
+    return x / y, x == math.MinInt64 && y == -1
+  case "%":
+    return x % y, false
+  case "**":
+    return integerPower(x, y)
+  case "<<":
+    result := x << uint64(y)
+    return result, result>>uint64(y) != x
+  }
+
+  panic("unknown arithmetic operator: " + operator)
+}
+
+// integerPower raises x to the power y by repeated squaring. A square is
+// only computed when it is needed for the result, so the result overflows
+// exactly when one of the multiplications does.
+func integerPower(x, y int64) (int64, bool) {
+  result, overflow := int64(1), false
+
+  for y > 0 {
+    if y&1 == 1 {
+      product, o := integerArithmetic("*", result, x)
+      result, overflow = product, overflow || o
+    }
+
+    y >>= 1
+
+    if y > 0 {
+      square, o := integerArithmetic("*", x, x)
+      x, overflow = square, overflow || o
+    }
+  }
+
+  return result, overflow
+}
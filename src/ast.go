@@ -2,10 +2,13 @@ package main
 
 import (
   "bytes"
+  "math/big"
+  "strconv"
   "strings"
 )
 
 type Node interface {
+  Pos() Position
   TokenLiteral() string
   String() string
 }
@@ -32,6 +35,14 @@ func (p *Program) TokenLiteral() string {
   }
 }
 
+func (p *Program) Pos() Position {
+  if len(p.Statements) > 0 {
+    return p.Statements[0].Pos()
+  } else {
+    return Position{}
+  }
+}
+
 func (p *Program) String() string {
   var out bytes.Buffer
 
@@ -49,28 +60,71 @@ type Identifier struct {
 
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 
+func (i *Identifier) Pos() Position { return i.Token.Position }
+
 func (i *Identifier) expressionNode() {}
 
 func (i *Identifier) String() string {
   return i.Value
 }
 
+// IntegerLiteral is an integer written in the source. Big holds the value
+// of literals too large for an int64, in which case Value is unused.
 type IntegerLiteral struct {
   Token Token
   Value int64
+  Big   *big.Int
 }
 
 func (i *IntegerLiteral) TokenLiteral() string { return i.Token.Literal }
 
+func (i *IntegerLiteral) Pos() Position { return i.Token.Position }
+
 func (i *IntegerLiteral) expressionNode() {}
 
 func (i *IntegerLiteral) String() string {
   return i.Token.Literal
 }
 
+type FloatLiteral struct {
+  Token Token
+  Value float64
+}
+
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+
+func (fl *FloatLiteral) Pos() Position { return fl.Token.Position }
+
+func (fl *FloatLiteral) expressionNode() {}
+
+func (fl *FloatLiteral) String() string {
+  return fl.Token.Literal
+}
+
+type StringLiteral struct {
+  Token Token
+  Value string
+}
+
+func (sl *StringLiteral) expressionNode() {}
+
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+
+func (sl *StringLiteral) Pos() Position { return sl.Token.Position }
+
+func (sl *StringLiteral) String() string {
+  return strconv.Quote(sl.Value)
+}
+
+// FunctionLiteral is a function expression. Defaults maps the names of
+// its optional parameters to their default values, and Rest, if present,
+// is the parameter that collects any further arguments.
 type FunctionLiteral struct {
   Token      Token
+  Name       string
   Parameters []*Identifier
+  Defaults   map[string]Expression
+  Rest       *Identifier
   Body       *BlockStatement
 }
 
@@ -78,14 +132,12 @@ func (fl *FunctionLiteral) expressionNode() {}
 
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
 
+func (fl *FunctionLiteral) Pos() Position { return fl.Token.Position }
+
 func (fl *FunctionLiteral) String() string {
   var out bytes.Buffer
 
-  params := []string{}
-
-  for _, p := range fl.Parameters {
-    params = append(params, p.String())
-  }
+  params := parameterStrings(fl.Parameters, fl.Defaults, fl.Rest)
 
   out.WriteString(fl.TokenLiteral())
   out.WriteString("(")
@@ -96,16 +148,43 @@ func (fl *FunctionLiteral) String() string {
   return out.String()
 }
 
+func parameterStrings(
+  parameters []*Identifier,
+  defaults map[string]Expression,
+  rest *Identifier,
+) []string {
+  params := []string{}
+
+  for _, p := range parameters {
+    if value, ok := defaults[p.Value]; ok {
+      params = append(params, p.String()+" = "+value.String())
+    } else {
+      params = append(params, p.String())
+    }
+  }
+
+  if rest != nil {
+    params = append(params, ELLIPSIS+rest.String())
+  }
+
+  return params
+}
+
+// LetStatement binds a name to a value. Names bound with `const` cannot be
+// assigned to or bound again in the same scope.
 type LetStatement struct {
-  Token Token
-  Name  *Identifier
-  Value Expression
+  Token    Token
+  Name     *Identifier
+  Value    Expression
+  Constant bool
 }
 
 func (ls *LetStatement) TokenLiteral() string {
   return ls.Token.Literal
 }
 
+func (ls *LetStatement) Pos() Position { return ls.Token.Position }
+
 func (ls *LetStatement) statementNode() {}
 
 func (ls *LetStatement) String() string {
@@ -135,6 +214,8 @@ func (rs *ReturnStatement) TokenLiteral() string {
   return rs.Token.Literal
 }
 
+func (rs *ReturnStatement) Pos() Position { return rs.Token.Position }
+
 func (rs *ReturnStatement) String() string {
   var out bytes.Buffer
 
@@ -149,6 +230,81 @@ func (rs *ReturnStatement) String() string {
   return out.String()
 }
 
+type WhileStatement struct {
+  Token     Token
+  Condition Expression
+  Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+
+func (ws *WhileStatement) Pos() Position { return ws.Token.Position }
+
+func (ws *WhileStatement) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("while")
+  out.WriteString(ws.Condition.String())
+  out.WriteString(" ")
+  out.WriteString(ws.Body.String())
+
+  return out.String()
+}
+
+// ForStatement runs its body once for each element of an iterable, bound
+// to Variable.
+type ForStatement struct {
+  Token    Token
+  Variable *Identifier
+  Iterable Expression
+  Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+
+func (fs *ForStatement) Pos() Position { return fs.Token.Position }
+
+func (fs *ForStatement) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("for (")
+  out.WriteString(fs.Variable.String())
+  out.WriteString(" in ")
+  out.WriteString(fs.Iterable.String())
+  out.WriteString(") ")
+  out.WriteString(fs.Body.String())
+
+  return out.String()
+}
+
+type BreakStatement struct {
+  Token Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+
+func (bs *BreakStatement) Pos() Position { return bs.Token.Position }
+
+func (bs *BreakStatement) String() string { return bs.TokenLiteral() + ";" }
+
+type ContinueStatement struct {
+  Token Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+
+func (cs *ContinueStatement) Pos() Position { return cs.Token.Position }
+
+func (cs *ContinueStatement) String() string { return cs.TokenLiteral() + ";" }
+
 type ExpressionStatement struct {
   Token      Token
   Expression Expression
@@ -160,6 +316,8 @@ func (es *ExpressionStatement) TokenLiteral() string {
   return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() Position { return es.Token.Position }
+
 func (es *ExpressionStatement) String() string {
   if es.Expression != nil {
     return es.Expression.String()
@@ -177,6 +335,8 @@ func (bs *BlockStatement) statementNode() {}
 
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
 
+func (bs *BlockStatement) Pos() Position { return bs.Token.Position }
+
 func (bs *BlockStatement) String() string {
   var out bytes.Buffer
 
@@ -197,6 +357,8 @@ func (pe *PrefixExpression) expressionNode() {}
 
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 
+func (pe *PrefixExpression) Pos() Position { return pe.Token.Position }
+
 func (pe *PrefixExpression) String() string {
   var out bytes.Buffer
 
@@ -219,6 +381,8 @@ func (ie *InfixExpression) expressionNode() {}
 
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 
+func (ie *InfixExpression) Pos() Position { return ie.Token.Position }
+
 func (ie *InfixExpression) String() string {
   var out bytes.Buffer
 
@@ -240,8 +404,37 @@ func (b *BooleanExpression) expressionNode() {}
 
 func (b *BooleanExpression) TokenLiteral() string { return b.Token.Literal }
 
+func (b *BooleanExpression) Pos() Position { return b.Token.Position }
+
 func (b *BooleanExpression) String() string { return b.Token.Literal }
 
+// AssignExpression rebinds an existing variable. Compound assignments such
+// as `x += 1` combine the variable's value with the new one.
+type AssignExpression struct {
+  Token    Token
+  Name     *Identifier
+  Operator string
+  Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+
+func (ae *AssignExpression) Pos() Position { return ae.Token.Position }
+
+func (ae *AssignExpression) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("(")
+  out.WriteString(ae.Name.String())
+  out.WriteString(" " + ae.Operator + " ")
+  out.WriteString(ae.Value.String())
+  out.WriteString(")")
+
+  return out.String()
+}
+
 type IfExpression struct {
   Token       Token
   Condition   Expression
@@ -253,6 +446,8 @@ func (ie *IfExpression) expressionNode() {}
 
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
 
+func (ie *IfExpression) Pos() Position { return ie.Token.Position }
+
 func (ie *IfExpression) String() string {
   var out bytes.Buffer
 
@@ -279,6 +474,8 @@ func (ce *CallExpression) expressionNode() {}
 
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
 
+func (ce *CallExpression) Pos() Position { return ce.Token.Position }
+
 func (ce *CallExpression) String() string {
   var out bytes.Buffer
 
@@ -295,3 +492,98 @@ func (ce *CallExpression) String() string {
 
   return out.String()
 }
+
+// SpreadExpression expands an array into the arguments of a call.
+type SpreadExpression struct {
+  Token Token
+  Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+
+func (se *SpreadExpression) Pos() Position { return se.Token.Position }
+
+func (se *SpreadExpression) String() string {
+  return se.TokenLiteral() + se.Value.String()
+}
+
+type ArrayLiteral struct {
+  Token    Token
+  Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+
+func (al *ArrayLiteral) Pos() Position { return al.Token.Position }
+
+func (al *ArrayLiteral) String() string {
+  var out bytes.Buffer
+
+  elements := []string{}
+
+  for _, el := range al.Elements {
+    elements = append(elements, el.String())
+  }
+
+  out.WriteString("[")
+  out.WriteString(strings.Join(elements, ", "))
+  out.WriteString("]")
+
+  return out.String()
+}
+
+type IndexExpression struct {
+  Token Token
+  Left  Expression
+  Index Expression
+}
+
+func (ie *IndexExpression) expressionNode() {}
+
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+
+func (ie *IndexExpression) Pos() Position { return ie.Token.Position }
+
+func (ie *IndexExpression) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("(")
+  out.WriteString(ie.Left.String())
+  out.WriteString("[")
+  out.WriteString(ie.Index.String())
+  out.WriteString("])")
+
+  return out.String()
+}
+
+type HashLiteral struct {
+  Token  Token
+  Keys   []Expression
+  Values []Expression
+}
+
+func (hl *HashLiteral) expressionNode() {}
+
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+
+func (hl *HashLiteral) Pos() Position { return hl.Token.Position }
+
+func (hl *HashLiteral) String() string {
+  var out bytes.Buffer
+
+  pairs := []string{}
+
+  for i, key := range hl.Keys {
+    pairs = append(pairs, key.String()+": "+hl.Values[i].String())
+  }
+
+  out.WriteString("{")
+  out.WriteString(strings.Join(pairs, ", "))
+  out.WriteString("}")
+
+  return out.String()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+  "fmt"
+  "math"
+  "math/big"
+  "os"
+  "strings"
+  "unicode/utf8"
+)
+
+var builtins = map[string]*Builtin{
+  "exit":  {Name: "exit", Arity: Arity{0, 1}, Fn: builtinExit},
+  "first": {Name: "first", Arity: Arity{1, 1}, Fn: builtinFirst},
+  "int":   {Name: "int", Arity: Arity{1, 1}, Fn: builtinInt},
+  "last":  {Name: "last", Arity: Arity{1, 1}, Fn: builtinLast},
+  "len":   {Name: "len", Arity: Arity{1, 1}, Fn: builtinLen},
+  "push":  {Name: "push", Arity: Arity{2, 2}, Fn: builtinPush},
+  "puts":  {Name: "puts", Arity: Arity{0, VARIADIC}, Fn: builtinPuts},
+  "rest":  {Name: "rest", Arity: Arity{1, 1}, Fn: builtinRest},
+  "str":   {Name: "str", Arity: Arity{1, 1}, Fn: builtinStr},
+  "type":  {Name: "type", Arity: Arity{1, 1}, Fn: builtinType},
+}
+
+func builtinExit(args ...Object) Object {
+  code := int64(0)
+
+  if len(args) == 1 {
+    integer, ok := args[0].(*Integer)
+    if !ok {
+      return newError("argument to `exit` must be INTEGER, got %s",
+        args[0].Type())
+    }
+    code = integer.Value
+  }
+
+  os.Exit(int(code))
+
+  return NULL_LIT
+}
+
+func builtinFirst(args ...Object) Object {
+  array, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `first` must be ARRAY, got %s",
+      args[0].Type())
+  }
+
+  if len(array.Elements) == 0 {
+    return NULL_LIT
+  }
+
+  return array.Elements[0]
+}
+
+// builtinInt converts its argument to an integer. Floats are truncated
+// toward zero, and strings are parsed as decimal integers of any size.
+func builtinInt(args ...Object) Object {
+  switch arg := args[0].(type) {
+  case *Integer, *BigInt:
+    return arg
+  case *Boolean:
+    if arg.Value {
+      return &Integer{Value: 1}
+    }
+    return &Integer{Value: 0}
+  case *Float:
+    if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+      return newError("cannot convert %s to integer", arg.Inspect())
+    }
+    value, _ := big.NewFloat(arg.Value).Int(nil)
+    return newInteger(value)
+  case *String:
+    value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+    if !ok {
+      return newError("could not parse %q as integer", arg.Value)
+    }
+    return newInteger(value)
+  default:
+    return newError("argument to `int` not supported, got %s", arg.Type())
+  }
+}
+
+func builtinLast(args ...Object) Object {
+  array, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `last` must be ARRAY, got %s",
+      args[0].Type())
+  }
+
+  if len(array.Elements) == 0 {
+    return NULL_LIT
+  }
+
+  return array.Elements[len(array.Elements)-1]
+}
+
+func builtinLen(args ...Object) Object {
+  switch arg := args[0].(type) {
+  case *Array:
+    return &Integer{Value: int64(len(arg.Elements))}
+  case *Hash:
+    return &Integer{Value: int64(len(arg.Pairs))}
+  case *String:
+    return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+  default:
+    return newError("argument to `len` not supported, got %s", arg.Type())
+  }
+}
+
+func builtinPush(args ...Object) Object {
+  array, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `push` must be ARRAY, got %s",
+      args[0].Type())
+  }
+
+  length := len(array.Elements)
+
+  elements := make([]Object, length+1)
+  copy(elements, array.Elements)
+  elements[length] = args[1]
+
+  return &Array{Elements: elements}
+}
+
+func builtinPuts(args ...Object) Object {
+  for _, arg := range args {
+    fmt.Println(arg.Inspect())
+  }
+
+  return NULL_LIT
+}
+
+func builtinRest(args ...Object) Object {
+  array, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `rest` must be ARRAY, got %s",
+      args[0].Type())
+  }
+
+  length := len(array.Elements)
+
+  if length == 0 {
+    return NULL_LIT
+  }
+
+  elements := make([]Object, length-1)
+  copy(elements, array.Elements[1:length])
+
+  return &Array{Elements: elements}
+}
+
+func builtinStr(args ...Object) Object {
+  if str, ok := args[0].(*String); ok {
+    return str
+  }
+
+  return &String{Value: args[0].Inspect()}
+}
+
+func builtinType(args ...Object) Object {
+  return &String{Value: string(args[0].Type())}
+}
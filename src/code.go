@@ -0,0 +1,298 @@
+package main
+
+import (
+  "bytes"
+  "encoding/binary"
+  "fmt"
+)
+
+type Instructions []byte
+
+func (ins Instructions) String() string {
+  var out bytes.Buffer
+
+  i := 0
+
+  for i < len(ins) {
+    def, err := LookupOpcode(ins[i])
+
+    if err != nil {
+      fmt.Fprintf(&out, "ERROR: %s\n", err)
+      i++
+      continue
+    }
+
+    operands, read := ReadOperands(def, ins[i+1:])
+
+    fmt.Fprintf(&out, "%04d %s\n", i, ins.formatInstruction(def, operands))
+
+    i += 1 + read
+  }
+
+  return out.String()
+}
+
+func (ins Instructions) formatInstruction(
+  def *OpcodeDefinition,
+  operands []int,
+) string {
+  count := len(def.OperandWidths)
+
+  if len(operands) != count {
+    return fmt.Sprintf(
+      "ERROR: operand len %d does not match defined %d\n",
+      len(operands),
+      count,
+    )
+  }
+
+  switch count {
+  case 0:
+    return def.Name
+  case 1:
+    return fmt.Sprintf("%s %d", def.Name, operands[0])
+  case 2:
+    return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+  }
+
+  return fmt.Sprintf("ERROR: unhandled operand count for %s\n", def.Name)
+}
+
+type Opcode byte
+
+const (
+  OpAdd Opcode = iota
+  OpArray
+  OpBang
+  OpBitAnd
+  OpBitNot
+  OpBitOr
+  OpBitXor
+  OpCall
+  OpCallSpread
+  OpCaptureFree
+  OpCaptureLocal
+  OpCheckFree
+  OpCheckGlobal
+  OpCheckLocal
+  OpClosure
+  OpConstant
+  OpCurrentClosure
+  OpDiv
+  OpEqual
+  OpFalse
+  OpGetFree
+  OpGetGlobal
+  OpGetLocal
+  OpGreaterEqual
+  OpGreaterThan
+  OpHash
+  OpIndex
+  OpIterator
+  OpJump
+  OpJumpNotTruthy
+  OpJumpPassed
+  OpLessEqual
+  OpLessThan
+  OpMarkStack
+  OpMinus
+  OpModulo
+  OpMul
+  OpNext
+  OpNotEqual
+  OpNull
+  OpPop
+  OpPower
+  OpReturn
+  OpReturnValue
+  OpSetFree
+  OpSetGlobal
+  OpSetLocal
+  OpShiftLeft
+  OpShiftRight
+  OpSpread
+  OpSub
+  OpTrue
+  OpUnwindStack
+)
+
+type OpcodeDefinition struct {
+  Name          string
+  OperandWidths []int
+}
+
+var definitions = map[Opcode]*OpcodeDefinition{
+  OpAdd:            {"OpAdd", []int{}},
+  OpArray:          {"OpArray", []int{2}},
+  OpBang:           {"OpBang", []int{}},
+  OpBitAnd:         {"OpBitAnd", []int{}},
+  OpBitNot:         {"OpBitNot", []int{}},
+  OpBitOr:          {"OpBitOr", []int{}},
+  OpBitXor:         {"OpBitXor", []int{}},
+  OpCall:           {"OpCall", []int{2}},
+  OpCallSpread:     {"OpCallSpread", []int{2}},
+  OpCaptureFree:    {"OpCaptureFree", []int{2}},
+  OpCaptureLocal:   {"OpCaptureLocal", []int{2}},
+  OpCheckFree:      {"OpCheckFree", []int{2}},
+  OpCheckGlobal:    {"OpCheckGlobal", []int{2}},
+  OpCheckLocal:     {"OpCheckLocal", []int{2}},
+  OpClosure:        {"OpClosure", []int{2, 2}},
+  OpConstant:       {"OpConstant", []int{2}},
+  OpCurrentClosure: {"OpCurrentClosure", []int{}},
+  OpDiv:            {"OpDiv", []int{}},
+  OpEqual:          {"OpEqual", []int{}},
+  OpFalse:          {"OpFalse", []int{}},
+  OpGetFree:        {"OpGetFree", []int{2}},
+  OpGetGlobal:      {"OpGetGlobal", []int{2}},
+  OpGetLocal:       {"OpGetLocal", []int{2}},
+  OpGreaterEqual:   {"OpGreaterEqual", []int{}},
+  OpGreaterThan:    {"OpGreaterThan", []int{}},
+  OpHash:           {"OpHash", []int{2}},
+  OpIndex:          {"OpIndex", []int{}},
+  OpIterator:       {"OpIterator", []int{}},
+  OpJump:           {"OpJump", []int{2}},
+  OpJumpNotTruthy:  {"OpJumpNotTruthy", []int{2}},
+  OpJumpPassed:     {"OpJumpPassed", []int{2, 2}},
+  OpLessEqual:      {"OpLessEqual", []int{}},
+  OpLessThan:       {"OpLessThan", []int{}},
+  OpMarkStack:      {"OpMarkStack", []int{}},
+  OpMinus:          {"OpMinus", []int{}},
+  OpModulo:         {"OpModulo", []int{}},
+  OpMul:            {"OpMul", []int{}},
+  OpNext:           {"OpNext", []int{2}},
+  OpNotEqual:       {"OpNotEqual", []int{}},
+  OpNull:           {"OpNull", []int{}},
+  OpPop:            {"OpPop", []int{}},
+  OpPower:          {"OpPower", []int{}},
+  OpReturn:         {"OpReturn", []int{}},
+  OpReturnValue:    {"OpReturnValue", []int{}},
+  OpSetFree:        {"OpSetFree", []int{2}},
+  OpSetGlobal:      {"OpSetGlobal", []int{2}},
+  OpSetLocal:       {"OpSetLocal", []int{2}},
+  OpShiftLeft:      {"OpShiftLeft", []int{}},
+  OpShiftRight:     {"OpShiftRight", []int{}},
+  OpSpread:         {"OpSpread", []int{}},
+  OpSub:            {"OpSub", []int{}},
+  OpTrue:           {"OpTrue", []int{}},
+  OpUnwindStack:    {"OpUnwindStack", []int{}},
+}
+
+// infixOperators maps the opcodes emitted for infix expressions back to
+// the operator they were compiled from, so the virtual machine can share
+// the evaluator's operator semantics and error messages.
+var infixOperators = map[Opcode]string{
+  OpAdd:          "+",
+  OpBitAnd:       "&",
+  OpBitOr:        "|",
+  OpBitXor:       "^",
+  OpDiv:          "/",
+  OpEqual:        "==",
+  OpGreaterEqual: ">=",
+  OpGreaterThan:  ">",
+  OpLessEqual:    "<=",
+  OpLessThan:     "<",
+  OpModulo:       "%",
+  OpMul:          "*",
+  OpNotEqual:     "!=",
+  OpPower:        "**",
+  OpShiftLeft:    "<<",
+  OpShiftRight:   ">>",
+  OpSub:          "-",
+}
+
+var prefixOperators = map[Opcode]string{
+  OpBang:   "!",
+  OpBitNot: "~",
+  OpMinus:  "-",
+}
+
+func LookupOpcode(op byte) (*OpcodeDefinition, error) {
+  def, ok := definitions[Opcode(op)]
+
+  if !ok {
+    return nil, fmt.Errorf("opcode %d undefined", op)
+  }
+
+  return def, nil
+}
+
+func LookupInfixOpcode(operator string) (Opcode, bool) {
+  for op, candidate := range infixOperators {
+    if candidate == operator {
+      return op, true
+    }
+  }
+
+  return 0, false
+}
+
+func LookupPrefixOpcode(operator string) (Opcode, bool) {
+  for op, candidate := range prefixOperators {
+    if candidate == operator {
+      return op, true
+    }
+  }
+
+  return 0, false
+}
+
+func MakeInstruction(op Opcode, operands ...int) []byte {
+  def, ok := definitions[op]
+
+  if !ok {
+    return []byte{}
+  }
+
+  length := 1
+
+  for _, width := range def.OperandWidths {
+    length += width
+  }
+
+  instruction := make([]byte, length)
+  instruction[0] = byte(op)
+
+  offset := 1
+
+  for i, operand := range operands {
+    width := def.OperandWidths[i]
+
+    switch width {
+    case 1:
+      instruction[offset] = byte(operand)
+    case 2:
+      binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
+    }
+
+    offset += width
+  }
+
+  return instruction
+}
+
+func ReadOperands(def *OpcodeDefinition, ins Instructions) ([]int, int) {
+  operands := make([]int, len(def.OperandWidths))
+
+  offset := 0
+
+  for i, width := range def.OperandWidths {
+    switch width {
+    case 1:
+      operands[i] = int(ReadUint8(ins[offset:]))
+    case 2:
+      operands[i] = int(ReadUint16(ins[offset:]))
+    }
+
+    offset += width
+  }
+
+  return operands, offset
+}
+
+func ReadUint8(ins Instructions) uint8 {
+  return uint8(ins[0])
+}
+
+func ReadUint16(ins Instructions) uint16 {
+  return binary.BigEndian.Uint16(ins)
+}
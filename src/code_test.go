@@ -0,0 +1,110 @@
+package main
+
+import (
+  "testing"
+)
+
+func TestMakeInstruction(t *testing.T) {
+  tests := []struct {
+    op       Opcode
+    operands []int
+    expected []byte
+  }{
+    {OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+    {OpAdd, []int{}, []byte{byte(OpAdd)}},
+    {OpGetLocal, []int{256}, []byte{byte(OpGetLocal), 1, 0}},
+    {
+      OpClosure,
+      []int{65534, 255},
+      []byte{byte(OpClosure), 255, 254, 0, 255},
+    },
+  }
+
+  for _, tt := range tests {
+    instruction := MakeInstruction(tt.op, tt.operands...)
+
+    if len(instruction) != len(tt.expected) {
+      t.Errorf("instruction has wrong length. want=%d, got=%d",
+        len(tt.expected), len(instruction))
+      continue
+    }
+
+    for i, b := range tt.expected {
+      if instruction[i] != b {
+        t.Errorf("wrong byte at pos %d. want=%d, got=%d",
+          i, b, instruction[i])
+      }
+    }
+  }
+}
+
+func TestInstructionsString(t *testing.T) {
+  instructions := []Instructions{
+    MakeInstruction(OpAdd),
+    MakeInstruction(OpGetLocal, 1),
+    MakeInstruction(OpConstant, 2),
+    MakeInstruction(OpConstant, 65535),
+    MakeInstruction(OpClosure, 65535, 255),
+  }
+
+  expected := `0000 OpAdd
+0001 OpGetLocal 1
+0004 OpConstant 2
+0007 OpConstant 65535
+0010 OpClosure 65535 255
+`
+
+  concatted := Instructions{}
+
+  for _, ins := range instructions {
+    concatted = append(concatted, ins...)
+  }
+
+  if concatted.String() != expected {
+    t.Errorf("instructions wrongly formatted.\nwant=%q\ngot=%q",
+      expected, concatted.String())
+  }
+}
+
+func TestReadOperands(t *testing.T) {
+  tests := []struct {
+    op        Opcode
+    operands  []int
+    bytesRead int
+  }{
+    {OpConstant, []int{65535}, 2},
+    {OpGetLocal, []int{65535}, 2},
+    {OpClosure, []int{65535, 255}, 4},
+  }
+
+  for _, tt := range tests {
+    instruction := MakeInstruction(tt.op, tt.operands...)
+
+    def, err := LookupOpcode(byte(tt.op))
+    if err != nil {
+      t.Fatalf("definition not found: %q\n", err)
+    }
+
+    operandsRead, n := ReadOperands(def, instruction[1:])
+    if n != tt.bytesRead {
+      t.Fatalf("n wrong. want=%d, got=%d", tt.bytesRead, n)
+    }
+
+    for i, want := range tt.operands {
+      if operandsRead[i] != want {
+        t.Errorf("operand wrong. want=%d, got=%d", want, operandsRead[i])
+      }
+    }
+  }
+}
+
+func TestInfixOpcodesRoundTrip(t *testing.T) {
+  for op, operator := range infixOperators {
+    found, ok := LookupInfixOpcode(operator)
+
+    if !ok || found != op {
+      t.Errorf("operator %q does not map back to %s",
+        operator, definitions[op].Name)
+    }
+  }
+}
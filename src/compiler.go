@@ -0,0 +1,919 @@
+package main
+
+import (
+  "fmt"
+  "strings"
+)
+
+type Bytecode struct {
+  Instructions Instructions
+  Constants    []Object
+  GlobalNames  []string
+  SourceMap    SourceMap
+}
+
+// SourceMap records the source position each instruction was compiled
+// from, keyed by the instruction's offset.
+type SourceMap map[int]Position
+
+// Lookup returns the position of the instruction containing offset.
+func (s SourceMap) Lookup(offset int) Position {
+  for ; offset >= 0; offset-- {
+    if position, ok := s[offset]; ok {
+      return position
+    }
+  }
+
+  return Position{}
+}
+
+type EmittedInstruction struct {
+  Opcode   Opcode
+  Position int
+}
+
+type CompilationScope struct {
+  instructions        Instructions
+  lastInstruction     EmittedInstruction
+  loops               []*Loop
+  previousInstruction EmittedInstruction
+  sourceMap           SourceMap
+}
+
+// Loop tracks the jumps of a loop being compiled: `continue` jumps back to
+// its start, and `break` jumps past its end once that is known. Both first
+// unwind the stack to the height saved in the hidden variable stack.
+type Loop struct {
+  breaks []int
+  stack  Symbol
+  start  int
+}
+
+func NewCompilationScope() CompilationScope {
+  return CompilationScope{
+    instructions: Instructions{},
+    sourceMap:    SourceMap{},
+  }
+}
+
+type Compiler struct {
+  constants  []Object
+  overflow   *Error
+  position   Position
+  scopeIndex int
+  scopes     []CompilationScope
+  symbols    *SymbolTable
+}
+
+func NewCompiler() *Compiler {
+  return NewCompilerWithState(NewSymbolTable(), []Object{})
+}
+
+// NewCompilerWithState creates a compiler that continues from a previous
+// compilation, which lets the REPL keep globals alive between lines.
+func NewCompilerWithState(symbols *SymbolTable, constants []Object) *Compiler {
+  return &Compiler{
+    constants: constants,
+    scopes:    []CompilationScope{NewCompilationScope()},
+    symbols:   symbols,
+  }
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+  return &Bytecode{
+    Instructions: c.currentInstructions(),
+    Constants:    c.constants,
+    GlobalNames:  c.symbols.Names(),
+    SourceMap:    c.scopes[c.scopeIndex].sourceMap,
+  }
+}
+
+// Compile compiles node. If the program is too large for the operands of
+// some instruction, an error is returned rather than bytecode that would
+// silently misbehave.
+func (c *Compiler) Compile(node Node) (err error) {
+  outer := c.position
+  c.position = node.Pos()
+
+  defer func() {
+    c.position = outer
+
+    if err == nil && c.overflow != nil {
+      err = c.overflow
+    }
+  }()
+
+  switch node := node.(type) {
+  case *Program:
+    for _, statement := range node.Statements {
+      if err := c.Compile(statement); err != nil {
+        return err
+      }
+    }
+  case *BlockStatement:
+    for _, statement := range node.Statements {
+      if err := c.Compile(statement); err != nil {
+        return err
+      }
+    }
+  case *ExpressionStatement:
+    if err := c.Compile(node.Expression); err != nil {
+      return err
+    }
+    c.emit(OpPop)
+  case *LetStatement:
+    return c.compileLetStatement(node)
+  case *ReturnStatement:
+    if err := c.Compile(node.ReturnValue); err != nil {
+      return err
+    }
+    c.emit(OpReturnValue)
+  case *WhileStatement:
+    return c.compileWhileStatement(node)
+  case *ForStatement:
+    return c.compileForStatement(node)
+  case *BreakStatement:
+    loop := c.currentLoop()
+    c.unwindLoop(loop)
+    loop.breaks = append(loop.breaks, c.emit(OpJump, 9999))
+  case *ContinueStatement:
+    loop := c.currentLoop()
+    c.unwindLoop(loop)
+    c.emit(OpJump, loop.start)
+  case *InfixExpression:
+    if node.Operator == AND || node.Operator == OR {
+      return c.compileLogicalExpression(node)
+    }
+    op, ok := LookupInfixOpcode(node.Operator)
+    if !ok {
+      return c.error("unknown operator: %s", node.Operator)
+    }
+    if err := c.Compile(node.Left); err != nil {
+      return err
+    }
+    if err := c.Compile(node.Right); err != nil {
+      return err
+    }
+    c.emit(op)
+  case *PrefixExpression:
+    op, ok := LookupPrefixOpcode(node.Operator)
+    if !ok {
+      return c.error("unknown operator: %s", node.Operator)
+    }
+    if err := c.Compile(node.Right); err != nil {
+      return err
+    }
+    c.emit(op)
+  case *AssignExpression:
+    return c.compileAssignExpression(node)
+  case *IfExpression:
+    return c.compileIfExpression(node)
+  case *IntegerLiteral:
+    if node.Big != nil {
+      c.emit(OpConstant, c.addConstant(&BigInt{Value: node.Big}))
+    } else {
+      c.emit(OpConstant, c.addConstant(&Integer{Value: node.Value}))
+    }
+  case *FloatLiteral:
+    c.emit(OpConstant, c.addConstant(&Float{Value: node.Value}))
+  case *StringLiteral:
+    c.emit(OpConstant, c.addConstant(&String{Value: node.Value}))
+  case *BooleanExpression:
+    if node.Value {
+      c.emit(OpTrue)
+    } else {
+      c.emit(OpFalse)
+    }
+  case *Identifier:
+    return c.compileIdentifier(node)
+  case *ArrayLiteral:
+    for _, element := range node.Elements {
+      if err := c.Compile(element); err != nil {
+        return err
+      }
+    }
+    c.emit(OpArray, len(node.Elements))
+  case *HashLiteral:
+    for i, key := range node.Keys {
+      if err := c.Compile(key); err != nil {
+        return err
+      }
+      if err := c.Compile(node.Values[i]); err != nil {
+        return err
+      }
+    }
+    c.emit(OpHash, len(node.Keys)*2)
+  case *IndexExpression:
+    if err := c.Compile(node.Left); err != nil {
+      return err
+    }
+    if err := c.Compile(node.Index); err != nil {
+      return err
+    }
+    c.emit(OpIndex)
+  case *FunctionLiteral:
+    return c.compileFunctionLiteral(node)
+  case *CallExpression:
+    return c.compileCallExpression(node)
+  case *SpreadExpression:
+    if err := c.Compile(node.Value); err != nil {
+      return err
+    }
+    c.emit(OpSpread)
+  default:
+    return c.error("unsupported node: %T", node)
+  }
+
+  return nil
+}
+
+// compileLetStatement binds a value to a name. A function is bound before
+// it is compiled, so that its body can assign to the name.
+func (c *Compiler) compileLetStatement(node *LetStatement) error {
+  name := node.Name.Value
+
+  if c.symbols.Constant(name) {
+    return c.error("redeclaration of constant: %s", name)
+  }
+
+  define := c.symbols.Define
+
+  if node.Constant {
+    define = c.symbols.DefineConstant
+  }
+
+  if literal, ok := node.Value.(*FunctionLiteral); ok && literal.Name != "" {
+    define(name)
+  }
+
+  if err := c.Compile(node.Value); err != nil {
+    return err
+  }
+
+  c.storeSymbol(define(name))
+
+  return nil
+}
+
+func (c *Compiler) compileIfExpression(node *IfExpression) error {
+  if err := c.Compile(node.Condition); err != nil {
+    return err
+  }
+
+  jumpNotTruthyPosition := c.emit(OpJumpNotTruthy, 9999)
+
+  if err := c.compileBlockValue(node.Consequence); err != nil {
+    return err
+  }
+
+  jumpPosition := c.emit(OpJump, 9999)
+
+  c.changeOperand(jumpNotTruthyPosition, len(c.currentInstructions()))
+
+  if node.Alternative == nil {
+    c.emit(OpNull)
+  } else if err := c.compileBlockValue(node.Alternative); err != nil {
+    return err
+  }
+
+  c.changeOperand(jumpPosition, len(c.currentInstructions()))
+
+  return nil
+}
+
+// compileLogicalExpression compiles `&&` and `||`, jumping over the right
+// operand when the left one decides the result.
+func (c *Compiler) compileLogicalExpression(node *InfixExpression) error {
+  if err := c.Compile(node.Left); err != nil {
+    return err
+  }
+
+  jumpRightPosition := c.emit(OpJumpNotTruthy, 9999)
+
+  // The left operand of `||` was truthy.
+  jumpTruePosition := -1
+  if node.Operator == OR {
+    jumpTruePosition = c.emit(OpJump, 9999)
+    c.changeOperand(jumpRightPosition, len(c.currentInstructions()))
+  }
+
+  if err := c.Compile(node.Right); err != nil {
+    return err
+  }
+
+  jumpFalsePosition := c.emit(OpJumpNotTruthy, 9999)
+
+  if jumpTruePosition != -1 {
+    c.changeOperand(jumpTruePosition, len(c.currentInstructions()))
+  }
+
+  c.emit(OpTrue)
+  jumpEndPosition := c.emit(OpJump, 9999)
+
+  c.changeOperand(jumpFalsePosition, len(c.currentInstructions()))
+
+  // The left operand of `&&` was falsy.
+  if node.Operator == AND {
+    c.changeOperand(jumpRightPosition, len(c.currentInstructions()))
+  }
+
+  c.emit(OpFalse)
+
+  c.changeOperand(jumpEndPosition, len(c.currentInstructions()))
+
+  return nil
+}
+
+func (c *Compiler) compileWhileStatement(node *WhileStatement) error {
+  loop := c.enterLoop()
+
+  if err := c.Compile(node.Condition); err != nil {
+    return err
+  }
+
+  loop.breaks = append(loop.breaks, c.emit(OpJumpNotTruthy, 9999))
+
+  if err := c.Compile(node.Body); err != nil {
+    return err
+  }
+
+  c.emit(OpJump, loop.start)
+
+  c.leaveLoop()
+
+  return nil
+}
+
+// compileForStatement compiles a loop that keeps an iterator over its
+// iterable in a hidden variable, whose name cannot clash with that of any
+// variable in the program.
+func (c *Compiler) compileForStatement(node *ForStatement) error {
+  if c.symbols.Constant(node.Variable.Value) {
+    return c.error("redeclaration of constant: %s", node.Variable.Value)
+  }
+
+  if err := c.Compile(node.Iterable); err != nil {
+    return err
+  }
+
+  c.emit(OpIterator)
+
+  iterator := c.symbols.Define(
+    fmt.Sprintf("@iterator%d", len(c.scopes[c.scopeIndex].loops)),
+  )
+  c.storeSymbol(iterator)
+
+  loop := c.enterLoop()
+
+  c.loadSymbol(iterator)
+  loop.breaks = append(loop.breaks, c.emit(OpNext, 9999))
+  c.storeSymbol(c.symbols.Define(node.Variable.Value))
+
+  if err := c.Compile(node.Body); err != nil {
+    return err
+  }
+
+  c.emit(OpJump, loop.start)
+
+  c.leaveLoop()
+
+  return nil
+}
+
+// enterLoop starts a loop, first saving the height of the stack, which
+// `break` and `continue` return to when they leave an expression early.
+func (c *Compiler) enterLoop() *Loop {
+  scope := &c.scopes[c.scopeIndex]
+
+  stack := c.symbols.Define(fmt.Sprintf("@stack%d", len(scope.loops)))
+  c.emit(OpMarkStack)
+  c.storeSymbol(stack)
+
+  loop := &Loop{stack: stack, start: len(scope.instructions)}
+  scope.loops = append(scope.loops, loop)
+  return loop
+}
+
+// unwindLoop drops the values that the expressions enclosing a `break` or
+// `continue` have left on the stack.
+func (c *Compiler) unwindLoop(loop *Loop) {
+  c.loadSymbol(loop.stack)
+  c.emit(OpUnwindStack)
+}
+
+func (c *Compiler) currentLoop() *Loop {
+  loops := c.scopes[c.scopeIndex].loops
+  return loops[len(loops)-1]
+}
+
+// leaveLoop points the loop's breaks past its end. A loop evaluates to
+// null, as it does in `Eval`.
+func (c *Compiler) leaveLoop() {
+  scope := &c.scopes[c.scopeIndex]
+  loop := scope.loops[len(scope.loops)-1]
+  scope.loops = scope.loops[:len(scope.loops)-1]
+
+  for _, position := range loop.breaks {
+    c.changeOperand(position, len(c.currentInstructions()))
+  }
+
+  c.emit(OpNull)
+  c.emit(OpPop)
+}
+
+// compileBlockValue compiles a block that is used as an expression, leaving
+// the value of its final expression statement on the stack, or null when
+// the block does not end in one.
+func (c *Compiler) compileBlockValue(block *BlockStatement) error {
+  if err := c.Compile(block); err != nil {
+    return err
+  }
+
+  if c.lastInstructionIs(OpPop) {
+    c.removeLastPop()
+  } else {
+    c.emit(OpNull)
+  }
+
+  return nil
+}
+
+func (c *Compiler) compileIdentifier(node *Identifier) error {
+  if symbol, ok := c.symbols.Resolve(node.Value); ok {
+    c.loadSymbol(symbol)
+    return nil
+  }
+
+  if builtin, ok := builtins[node.Value]; ok {
+    c.emit(OpConstant, c.addConstant(builtin))
+    return nil
+  }
+
+  // The name may be a variable defined later, so it is read from the slot
+  // the variable will occupy, which reports it as not found if it is still
+  // unset when the instruction runs.
+  c.loadSymbol(c.symbols.Reserve(node.Value))
+
+  return nil
+}
+
+// compileAssignExpression compiles an assignment, which leaves the value
+// assigned on the stack.
+func (c *Compiler) compileAssignExpression(node *AssignExpression) error {
+  symbol, ok := c.resolveAssignable(node.Name.Value)
+
+  // As with reads, the name may be a variable defined later, so whether it
+  // has been declared is checked when the assignment runs.
+  if !ok {
+    symbol = c.symbols.Reserve(node.Name.Value)
+  }
+
+  if symbol.Constant {
+    return c.error("assignment to constant: %s", node.Name.Value)
+  }
+
+  c.checkSymbol(symbol)
+
+  operator := strings.TrimSuffix(node.Operator, "=")
+
+  if operator != "" {
+    c.loadSymbol(symbol)
+  }
+
+  if err := c.Compile(node.Value); err != nil {
+    return err
+  }
+
+  if operator != "" {
+    op, ok := LookupInfixOpcode(operator)
+    if !ok {
+      return c.error("unknown operator: %s", operator)
+    }
+    c.emit(op)
+  }
+
+  c.storeSymbol(symbol)
+  c.loadSymbol(symbol)
+
+  return nil
+}
+
+// resolveAssignable resolves a name being assigned to. Inside the body of
+// a function bound with `const`, its name refers to the function itself,
+// but assigning to it must find the constant so that it can be rejected.
+func (c *Compiler) resolveAssignable(name string) (Symbol, bool) {
+  symbol, ok := c.symbols.Resolve(name)
+
+  if ok && symbol.Scope == FUNCTION_SCOPE {
+    restore := c.symbols.Hide(name)
+    defer restore()
+    symbol, ok = c.symbols.Resolve(name)
+  }
+
+  return symbol, ok
+}
+
+// compileCallExpression compiles a call. When arguments are spread, each
+// argument is compiled to an array, and the arrays are concatenated into
+// the argument list when the call is made.
+func (c *Compiler) compileCallExpression(node *CallExpression) error {
+  if err := c.Compile(node.Function); err != nil {
+    return err
+  }
+
+  spread := false
+
+  for _, argument := range node.Arguments {
+    if _, ok := argument.(*SpreadExpression); ok {
+      spread = true
+    }
+  }
+
+  for _, argument := range node.Arguments {
+    if err := c.Compile(argument); err != nil {
+      return err
+    }
+
+    if _, ok := argument.(*SpreadExpression); spread && !ok {
+      c.emit(OpArray, 1)
+    }
+  }
+
+  if spread {
+    c.emit(OpCallSpread, len(node.Arguments))
+  } else {
+    c.emit(OpCall, len(node.Arguments))
+  }
+
+  return nil
+}
+
+func (c *Compiler) compileFunctionLiteral(node *FunctionLiteral) error {
+  // A function bound with `const` can refer to itself directly. One bound
+  // with `let` must read its name through the variable, which may have been
+  // assigned a different value by the time the name is read.
+  constant := false
+
+  if node.Name != "" {
+    binding, ok := c.symbols.Resolve(node.Name)
+    constant = ok && binding.Constant
+  }
+
+  c.enterScope()
+
+  declared := map[string]bool{}
+  declaredNames(node.Body, declared)
+  c.symbols.Predeclare(declared)
+
+  if constant {
+    c.symbols.DefineFunctionName(node.Name)
+  }
+
+  for _, parameter := range node.Parameters {
+    c.symbols.Define(parameter.Value)
+  }
+
+  if node.Rest != nil {
+    c.symbols.Define(node.Rest.Value)
+  }
+
+  if err := c.compileDefaults(node); err != nil {
+    return err
+  }
+
+  if err := c.Compile(node.Body); err != nil {
+    return err
+  }
+
+  if c.lastInstructionIs(OpPop) {
+    c.replaceLastPopWithReturn()
+  }
+
+  if !c.lastInstructionIs(OpReturnValue) {
+    c.emit(OpReturn)
+  }
+
+  free := c.symbols.FreeSymbols
+  numLocals := c.symbols.numDefinitions
+  localNames := c.symbols.Names()
+  sourceMap := c.scopes[c.scopeIndex].sourceMap
+  instructions := c.leaveScope()
+
+  freeNames := []string{}
+
+  for _, symbol := range free {
+    c.captureSymbol(symbol)
+    freeNames = append(freeNames, symbol.Name)
+  }
+
+  source := inspectFunction(
+    node.Parameters,
+    node.Defaults,
+    node.Rest,
+    node.Body,
+  )
+
+  function := &CompiledFunction{
+    Name:          node.Name,
+    Instructions:  instructions,
+    LocalNames:    localNames,
+    FreeNames:     freeNames,
+    NumLocals:     numLocals,
+    NumParameters: len(node.Parameters),
+    NumDefaults:   len(node.Defaults),
+    Variadic:      node.Rest != nil,
+    SourceMap:     sourceMap,
+    Source:        source,
+  }
+
+  c.emit(OpClosure, c.addConstant(function), len(free))
+
+  return nil
+}
+
+// declaredNames collects into names the variables that node binds in the
+// scope of the function it belongs to, without looking into the functions
+// nested inside it.
+func declaredNames(node Node, names map[string]bool) {
+  switch node := node.(type) {
+  case *BlockStatement:
+    for _, statement := range node.Statements {
+      declaredNames(statement, names)
+    }
+  case *LetStatement:
+    names[node.Name.Value] = true
+    declaredNames(node.Value, names)
+  case *ReturnStatement:
+    declaredNames(node.ReturnValue, names)
+  case *ExpressionStatement:
+    declaredNames(node.Expression, names)
+  case *WhileStatement:
+    declaredNames(node.Condition, names)
+    declaredNames(node.Body, names)
+  case *ForStatement:
+    names[node.Variable.Value] = true
+    declaredNames(node.Iterable, names)
+    declaredNames(node.Body, names)
+  case *PrefixExpression:
+    declaredNames(node.Right, names)
+  case *InfixExpression:
+    declaredNames(node.Left, names)
+    declaredNames(node.Right, names)
+  case *AssignExpression:
+    declaredNames(node.Value, names)
+  case *IfExpression:
+    declaredNames(node.Condition, names)
+    declaredNames(node.Consequence, names)
+    if node.Alternative != nil {
+      declaredNames(node.Alternative, names)
+    }
+  case *CallExpression:
+    declaredNames(node.Function, names)
+    for _, argument := range node.Arguments {
+      declaredNames(argument, names)
+    }
+  case *SpreadExpression:
+    declaredNames(node.Value, names)
+  case *ArrayLiteral:
+    for _, element := range node.Elements {
+      declaredNames(element, names)
+    }
+  case *IndexExpression:
+    declaredNames(node.Left, names)
+    declaredNames(node.Index, names)
+  case *HashLiteral:
+    for i := range node.Keys {
+      declaredNames(node.Keys[i], names)
+      declaredNames(node.Values[i], names)
+    }
+  }
+}
+
+// compileDefaults emits code at the start of a function that assigns
+// default values to the optional parameters the caller left out. Default
+// values are evaluated in the scope the function was defined in, so the
+// parameters are hidden while they are compiled.
+func (c *Compiler) compileDefaults(node *FunctionLiteral) error {
+  names := []string{}
+
+  for _, parameter := range node.Parameters {
+    names = append(names, parameter.Value)
+  }
+
+  if node.Rest != nil {
+    names = append(names, node.Rest.Value)
+  }
+
+  restore := c.symbols.Hide(names...)
+  defer restore()
+
+  for i, parameter := range node.Parameters {
+    value, ok := node.Defaults[parameter.Value]
+    if !ok {
+      continue
+    }
+
+    jumpPosition := c.emit(OpJumpPassed, i, 9999)
+
+    if err := c.Compile(value); err != nil {
+      return err
+    }
+
+    c.emit(OpSetLocal, i)
+
+    c.replaceInstruction(
+      jumpPosition,
+      c.makeInstruction(OpJumpPassed, i, len(c.currentInstructions())),
+    )
+  }
+
+  return nil
+}
+
+// error creates an error located at the node currently being compiled.
+func (c *Compiler) error(format string, a ...interface{}) *Error {
+  err := newError(format, a...)
+  err.Position = c.position
+  return err
+}
+
+func (c *Compiler) storeSymbol(symbol Symbol) {
+  switch symbol.Scope {
+  case GLOBAL_SCOPE:
+    c.emit(OpSetGlobal, symbol.Index)
+  case LOCAL_SCOPE:
+    c.emit(OpSetLocal, symbol.Index)
+  case FREE_SCOPE:
+    c.emit(OpSetFree, symbol.Index)
+  }
+}
+
+func (c *Compiler) loadSymbol(symbol Symbol) {
+  switch symbol.Scope {
+  case GLOBAL_SCOPE:
+    c.emit(OpGetGlobal, symbol.Index)
+  case LOCAL_SCOPE:
+    c.emit(OpGetLocal, symbol.Index)
+  case FREE_SCOPE:
+    c.emit(OpGetFree, symbol.Index)
+  case FUNCTION_SCOPE:
+    c.emit(OpCurrentClosure)
+  }
+}
+
+// checkSymbol fails unless a variable being assigned to has been declared,
+// which a reserved variable has not until its `let` runs.
+func (c *Compiler) checkSymbol(symbol Symbol) {
+  switch symbol.Scope {
+  case GLOBAL_SCOPE:
+    c.emit(OpCheckGlobal, symbol.Index)
+  case LOCAL_SCOPE:
+    c.emit(OpCheckLocal, symbol.Index)
+  case FREE_SCOPE:
+    c.emit(OpCheckFree, symbol.Index)
+  }
+}
+
+// captureSymbol pushes a variable that a closure being created refers to.
+// Variables are captured by reference, so that assignments made inside
+// and outside of the closure are seen by both.
+func (c *Compiler) captureSymbol(symbol Symbol) {
+  switch symbol.Scope {
+  case LOCAL_SCOPE:
+    c.emit(OpCaptureLocal, symbol.Index)
+  case FREE_SCOPE:
+    c.emit(OpCaptureFree, symbol.Index)
+  default:
+    c.loadSymbol(symbol)
+  }
+}
+
+func (c *Compiler) addConstant(obj Object) int {
+  c.constants = append(c.constants, obj)
+  return len(c.constants) - 1
+}
+
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+  instruction := c.makeInstruction(op, operands...)
+  position := c.addInstruction(instruction)
+  c.setLastInstruction(op, position)
+  c.scopes[c.scopeIndex].sourceMap[position] = c.position
+  return position
+}
+
+// makeInstruction encodes an instruction, recording an error if one of
+// its operands does not fit in the width the opcode gives it.
+func (c *Compiler) makeInstruction(op Opcode, operands ...int) []byte {
+  for i, width := range definitions[op].OperandWidths {
+    limit := 1<<(8*width) - 1
+
+    if operands[i] > limit && c.overflow == nil {
+      c.overflow = c.error(
+        "too many %s: the virtual machine supports at most %d",
+        describeOperand(op, i),
+        limit,
+      )
+    }
+  }
+
+  return MakeInstruction(op, operands...)
+}
+
+// describeOperand names what an operand of op counts or indexes.
+func describeOperand(op Opcode, i int) string {
+  switch op {
+  case OpArray:
+    return "array elements"
+  case OpCall, OpCallSpread:
+    return "arguments in a call"
+  case OpCaptureFree, OpCheckFree, OpGetFree, OpSetFree:
+    return "variables captured by a function"
+  case OpClosure:
+    if i == 1 {
+      return "variables captured by a function"
+    }
+    return "constants"
+  case OpConstant:
+    return "constants"
+  case OpCheckGlobal, OpGetGlobal, OpSetGlobal:
+    return "global variables"
+  case OpHash:
+    return "hash keys and values"
+  case OpCaptureLocal, OpCheckLocal, OpGetLocal, OpSetLocal:
+    return "local variables in a function"
+  case OpJumpPassed:
+    if i == 0 {
+      return "parameters in a function"
+    }
+  }
+
+  return "instructions in a function"
+}
+
+func (c *Compiler) addInstruction(instruction []byte) int {
+  position := len(c.currentInstructions())
+  c.scopes[c.scopeIndex].instructions = append(
+    c.currentInstructions(),
+    instruction...,
+  )
+  return position
+}
+
+func (c *Compiler) setLastInstruction(op Opcode, position int) {
+  scope := &c.scopes[c.scopeIndex]
+  scope.previousInstruction = scope.lastInstruction
+  scope.lastInstruction = EmittedInstruction{Opcode: op, Position: position}
+}
+
+func (c *Compiler) currentInstructions() Instructions {
+  return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op Opcode) bool {
+  if len(c.currentInstructions()) == 0 {
+    return false
+  }
+
+  return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+  scope := &c.scopes[c.scopeIndex]
+  scope.instructions = scope.instructions[:scope.lastInstruction.Position]
+  scope.lastInstruction = scope.previousInstruction
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+  position := c.scopes[c.scopeIndex].lastInstruction.Position
+  c.replaceInstruction(position, MakeInstruction(OpReturnValue))
+  c.scopes[c.scopeIndex].lastInstruction.Opcode = OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(position int, instruction []byte) {
+  ins := c.currentInstructions()
+
+  for i := 0; i < len(instruction); i++ {
+    ins[position+i] = instruction[i]
+  }
+}
+
+func (c *Compiler) changeOperand(position int, operand int) {
+  op := Opcode(c.currentInstructions()[position])
+  c.replaceInstruction(position, c.makeInstruction(op, operand))
+}
+
+func (c *Compiler) enterScope() {
+  c.scopes = append(c.scopes, NewCompilationScope())
+  c.scopeIndex++
+  c.symbols = NewEnclosedSymbolTable(c.symbols)
+}
+
+func (c *Compiler) leaveScope() Instructions {
+  instructions := c.currentInstructions()
+
+  c.scopes = c.scopes[:len(c.scopes)-1]
+  c.scopeIndex--
+  c.symbols = c.symbols.Outer
+
+  return instructions
+}
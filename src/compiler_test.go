@@ -0,0 +1,451 @@
+package main
+
+import (
+  "strings"
+  "testing"
+)
+
+type compilerTestCase struct {
+  input                string
+  expectedConstants    []interface{}
+  expectedInstructions []Instructions
+}
+
+func TestCompileIntegerArithmetic(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "1 + 2",
+      expectedConstants: []interface{}{1, 2},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpAdd),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input:             "1 < 2",
+      expectedConstants: []interface{}{1, 2},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpLessThan),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input:             "-1",
+      expectedConstants: []interface{}{1},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpMinus),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileConditionals(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "if (true) { 10 }; 3333;",
+      expectedConstants: []interface{}{10, 3333},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJumpNotTruthy, 10),
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpJump, 11),
+        MakeInstruction(OpNull),
+        MakeInstruction(OpPop),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input:             "if (true) { let a = 1; }",
+      expectedConstants: []interface{}{1},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJumpNotTruthy, 14),
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpNull),
+        MakeInstruction(OpJump, 15),
+        MakeInstruction(OpNull),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileLogicalOperators(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "true && false",
+      expectedConstants: []interface{}{},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJumpNotTruthy, 12),
+        MakeInstruction(OpFalse),
+        MakeInstruction(OpJumpNotTruthy, 12),
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJump, 13),
+        MakeInstruction(OpFalse),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input:             "true || false",
+      expectedConstants: []interface{}{},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJumpNotTruthy, 7),
+        MakeInstruction(OpJump, 11),
+        MakeInstruction(OpFalse),
+        MakeInstruction(OpJumpNotTruthy, 15),
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJump, 16),
+        MakeInstruction(OpFalse),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileGlobalLetStatements(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             `let one = 1; let two = "two"; one;`,
+      expectedConstants: []interface{}{1, "two"},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpSetGlobal, 1),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input: `let f = fn() { g }; let g = 1;`,
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpGetGlobal, 1),
+          MakeInstruction(OpReturnValue),
+        },
+        1,
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 0, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpSetGlobal, 1),
+      },
+    },
+  })
+}
+
+func TestCompileFunctions(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input: "fn(a) { let b = a; fn() { a + b } }",
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpGetFree, 0),
+          MakeInstruction(OpGetFree, 1),
+          MakeInstruction(OpAdd),
+          MakeInstruction(OpReturnValue),
+        },
+        []Instructions{
+          MakeInstruction(OpGetLocal, 0),
+          MakeInstruction(OpSetLocal, 1),
+          MakeInstruction(OpCaptureLocal, 0),
+          MakeInstruction(OpCaptureLocal, 1),
+          MakeInstruction(OpClosure, 0, 2),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 1, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input: "let f = fn() { f() };",
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpGetGlobal, 0),
+          MakeInstruction(OpCall, 0),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 0, 0),
+        MakeInstruction(OpSetGlobal, 0),
+      },
+    },
+    {
+      input: "const f = fn() { f() };",
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpCurrentClosure),
+          MakeInstruction(OpCall, 0),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 0, 0),
+        MakeInstruction(OpSetGlobal, 0),
+      },
+    },
+    {
+      input: "let b = 2; fn(a, b = b + 1) { b }",
+      expectedConstants: []interface{}{
+        2,
+        1,
+        []Instructions{
+          MakeInstruction(OpJumpPassed, 1, 15),
+          MakeInstruction(OpGetGlobal, 0),
+          MakeInstruction(OpConstant, 1),
+          MakeInstruction(OpAdd),
+          MakeInstruction(OpSetLocal, 1),
+          MakeInstruction(OpGetLocal, 1),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpClosure, 2, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input: "fn(a, ...b) { b }",
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpGetLocal, 1),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 0, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input: "fn() { }",
+      expectedConstants: []interface{}{
+        []Instructions{
+          MakeInstruction(OpReturn),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 0, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileSpreadArguments(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "let f = 0; f(1, ...[2])",
+      expectedConstants: []interface{}{0, 1, 2},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpArray, 1),
+        MakeInstruction(OpConstant, 2),
+        MakeInstruction(OpArray, 1),
+        MakeInstruction(OpSpread),
+        MakeInstruction(OpCallSpread, 2),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileLoops(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "while (true) { break; continue; }",
+      expectedConstants: []interface{}{},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpMarkStack),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpTrue),
+        MakeInstruction(OpJumpNotTruthy, 25),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpUnwindStack),
+        MakeInstruction(OpJump, 25),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpUnwindStack),
+        MakeInstruction(OpJump, 4),
+        MakeInstruction(OpJump, 4),
+        MakeInstruction(OpNull),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input:             "for (x in []) { x }",
+      expectedConstants: []interface{}{},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpArray, 0),
+        MakeInstruction(OpIterator),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpMarkStack),
+        MakeInstruction(OpSetGlobal, 1),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpNext, 27),
+        MakeInstruction(OpSetGlobal, 2),
+        MakeInstruction(OpGetGlobal, 2),
+        MakeInstruction(OpPop),
+        MakeInstruction(OpJump, 11),
+        MakeInstruction(OpNull),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileAssignments(t *testing.T) {
+  runCompilerTests(t, []compilerTestCase{
+    {
+      input:             "let x = 1; x += 2",
+      expectedConstants: []interface{}{1, 2},
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpConstant, 0),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpCheckGlobal, 0),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpConstant, 1),
+        MakeInstruction(OpAdd),
+        MakeInstruction(OpSetGlobal, 0),
+        MakeInstruction(OpGetGlobal, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+    {
+      input: "fn() { let n = 0; fn() { n = 1 } }",
+      expectedConstants: []interface{}{
+        0,
+        1,
+        []Instructions{
+          MakeInstruction(OpCheckFree, 0),
+          MakeInstruction(OpConstant, 1),
+          MakeInstruction(OpSetFree, 0),
+          MakeInstruction(OpGetFree, 0),
+          MakeInstruction(OpReturnValue),
+        },
+        []Instructions{
+          MakeInstruction(OpConstant, 0),
+          MakeInstruction(OpSetLocal, 0),
+          MakeInstruction(OpCaptureLocal, 0),
+          MakeInstruction(OpClosure, 2, 1),
+          MakeInstruction(OpReturnValue),
+        },
+      },
+      expectedInstructions: []Instructions{
+        MakeInstruction(OpClosure, 3, 0),
+        MakeInstruction(OpPop),
+      },
+    },
+  })
+}
+
+func TestCompileErrors(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {"const x = 1; x = 2", "assignment to constant: x"},
+    {"const x = 1; let x = 2", "redeclaration of constant: x"},
+    {
+      "[" + strings.Repeat("true, ", 70000) + "true]",
+      "too many array elements: the virtual machine supports at most 65535",
+    },
+  }
+
+  for _, tt := range tests {
+    err := NewCompiler().Compile(NewParser(NewLexer(tt.input)).Parse())
+
+    if err == nil {
+      t.Errorf("expected compiler error for %q", tt.input)
+      continue
+    }
+
+    if err.Error() != tt.expected {
+      t.Errorf("wrong error. want=%q, got=%q", tt.expected, err)
+    }
+  }
+}
+
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+  t.Helper()
+
+  for _, tt := range tests {
+    compiler := NewCompiler()
+
+    if err := compiler.Compile(setupProgram(t, tt.input)); err != nil {
+      t.Fatalf("compiler error: %s", err)
+    }
+
+    bytecode := compiler.Bytecode()
+
+    testInstructions(t, tt.expectedInstructions, bytecode.Instructions)
+    testConstants(t, tt.expectedConstants, bytecode.Constants)
+  }
+}
+
+func setupProgram(t *testing.T, input string) *Program {
+  program := setup(t, input)
+  return &program
+}
+
+func testInstructions(
+  t *testing.T,
+  expected []Instructions,
+  actual Instructions,
+) {
+  t.Helper()
+
+  concatted := Instructions{}
+
+  for _, ins := range expected {
+    concatted = append(concatted, ins...)
+  }
+
+  if concatted.String() != actual.String() {
+    t.Errorf("wrong instructions.\nwant=\n%s\ngot=\n%s", concatted, actual)
+  }
+}
+
+func testConstants(t *testing.T, expected []interface{}, actual []Object) {
+  t.Helper()
+
+  if len(expected) != len(actual) {
+    t.Fatalf("wrong number of constants. want=%d, got=%d",
+      len(expected), len(actual))
+  }
+
+  for i, constant := range expected {
+    switch constant := constant.(type) {
+    case int:
+      testIntegerObject(t, actual[i], int64(constant))
+    case string:
+      testStringObject(t, actual[i], constant)
+    case []Instructions:
+      fn, ok := actual[i].(*CompiledFunction)
+      if !ok {
+        t.Errorf("constant %d is not a function. got=%T", i, actual[i])
+        continue
+      }
+
+      testInstructions(t, constant, fn.Instructions)
+    }
+  }
+}
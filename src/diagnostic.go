@@ -0,0 +1,246 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+  "unicode/utf8"
+)
+
+type Severity string
+
+const (
+  SEVERITY_ERROR   Severity = "error"
+  SEVERITY_WARNING Severity = "warning"
+)
+
+// Span is the region of source text a diagnostic refers to. The end is
+// exclusive, and a span whose end is not after its start covers a single
+// character.
+type Span struct {
+  Start Position
+  End   Position
+}
+
+func TokenSpan(token Token) Span {
+  return Span{Start: token.Position, End: token.End}
+}
+
+func PositionSpan(position Position) Span {
+  return Span{Start: position, End: position}
+}
+
+type Diagnostic struct {
+  Severity Severity
+  Span     Span
+  Message  string
+  Notes    []string
+  Help     string
+}
+
+func errorDiagnostic(span Span, format string, a ...interface{}) Diagnostic {
+  return Diagnostic{
+    Severity: SEVERITY_ERROR,
+    Span:     span,
+    Message:  fmt.Sprintf(format, a...),
+  }
+}
+
+func (d Diagnostic) String() string {
+  if d.Span.Start.IsValid() {
+    return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+  }
+
+  return d.Message
+}
+
+// SourceError reports the diagnostics that stopped a source file from
+// running to completion.
+type SourceError struct {
+  Filename    string
+  Source      string
+  Diagnostics []Diagnostic
+}
+
+func (e *SourceError) Error() string {
+  var out strings.Builder
+
+  fmt.Fprintf(&out, "errors in file %s:", e.Filename)
+
+  for _, d := range e.Diagnostics {
+    fmt.Fprintf(&out, "\n\t%s", d)
+  }
+
+  return out.String()
+}
+
+const (
+  ansiBlue   = "\x1b[34m"
+  ansiBold   = "\x1b[1m"
+  ansiCyan   = "\x1b[36m"
+  ansiRed    = "\x1b[31m"
+  ansiReset  = "\x1b[0m"
+  ansiYellow = "\x1b[33m"
+)
+
+// Renderer prints diagnostics along with the source line they refer to,
+// underlining the offending span with carets:
+//
+//  error: type mismatch: INTEGER + BOOLEAN
+//   --> main.monk:2:5
+//    |
+//  2 |   x + true
+//    |     ^
+type Renderer struct {
+  Color    bool
+  Filename string
+  lines    []string
+}
+
+func NewRenderer(filename, source string, color bool) *Renderer {
+  return &Renderer{
+    Color:    color,
+    Filename: filename,
+    lines:    strings.Split(source, "\n"),
+  }
+}
+
+func (r *Renderer) Render(w io.Writer, diagnostics ...Diagnostic) {
+  for _, d := range diagnostics {
+    r.render(w, d)
+  }
+}
+
+func (r *Renderer) render(w io.Writer, d Diagnostic) {
+  severityColor := ansiRed
+
+  if d.Severity == SEVERITY_WARNING {
+    severityColor = ansiYellow
+  }
+
+  fmt.Fprintf(
+    w,
+    "%s%s\n",
+    r.paint(ansiBold+severityColor, string(d.Severity)+":"),
+    r.paint(ansiBold, " "+d.Message),
+  )
+
+  start := d.Span.Start
+
+  if !start.IsValid() || start.Line > len(r.lines) {
+    fmt.Fprintf(w, "%s %s\n", r.paint(ansiBlue, "-->"), r.Filename)
+    r.renderFooter(w, d, "")
+    return
+  }
+
+  line := strings.TrimRight(r.lines[start.Line-1], "\r")
+  number := strconv.Itoa(start.Line)
+  gutter := strings.Repeat(" ", len(number))
+
+  fmt.Fprintf(
+    w,
+    "%s%s %s:%s\n",
+    gutter,
+    r.paint(ansiBlue, "-->"),
+    r.Filename,
+    start,
+  )
+  fmt.Fprintf(w, "%s %s\n", gutter, r.paint(ansiBlue, "|"))
+  fmt.Fprintf(
+    w,
+    "%s %s %s\n",
+    r.paint(ansiBlue, number),
+    r.paint(ansiBlue, "|"),
+    line,
+  )
+
+  carets := strings.Repeat("^", spanWidth(d.Span, line))
+
+  fmt.Fprintf(
+    w,
+    "%s %s %s%s\n",
+    gutter,
+    r.paint(ansiBlue, "|"),
+    indentation(line, start.Column-1),
+    r.paint(ansiBold+severityColor, carets),
+  )
+
+  r.renderFooter(w, d, gutter)
+}
+
+func (r *Renderer) renderFooter(w io.Writer, d Diagnostic, gutter string) {
+  for _, note := range d.Notes {
+    fmt.Fprintf(w, "%s %s note: %s\n", gutter, r.paint(ansiBlue, "="), note)
+  }
+
+  if d.Help != "" {
+    fmt.Fprintf(
+      w,
+      "%s %s %s %s\n",
+      gutter,
+      r.paint(ansiBlue, "="),
+      r.paint(ansiBold+ansiCyan, "help:"),
+      d.Help,
+    )
+  }
+}
+
+func (r *Renderer) paint(color, text string) string {
+  if !r.Color {
+    return text
+  }
+
+  return color + text + ansiReset
+}
+
+// indentation returns whitespace as wide as the first n characters of line,
+// keeping tabs so carets line up with the source however tabs render.
+func indentation(line string, n int) string {
+  var out strings.Builder
+
+  for _, ch := range line {
+    if n == 0 {
+      break
+    }
+
+    if ch == '\t' {
+      out.WriteByte('\t')
+    } else {
+      out.WriteByte(' ')
+    }
+
+    n--
+  }
+
+  out.WriteString(strings.Repeat(" ", n))
+
+  return out.String()
+}
+
+func spanWidth(span Span, line string) int {
+  start, end := span.Start, span.End
+
+  if end.Line != start.Line {
+    end.Column = utf8.RuneCountInString(line) + 1
+  }
+
+  if end.Column <= start.Column {
+    return 1
+  }
+
+  return end.Column - start.Column
+}
+
+// isTerminal reports whether f is attached to a terminal, in which case
+// diagnostics are colored unless the NO_COLOR environment variable is set.
+func isTerminal(f *os.File) bool {
+  if os.Getenv("NO_COLOR") != "" {
+    return false
+  }
+
+  info, err := f.Stat()
+
+  return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
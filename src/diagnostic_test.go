@@ -0,0 +1,160 @@
+package main
+
+import (
+  "bytes"
+  "testing"
+)
+
+func TestParserDiagnostics(t *testing.T) {
+  parser := NewParser(NewLexer("let x = 1;\nlet foo 5;"))
+  parser.Parse()
+
+  diagnostics := parser.Diagnostics()
+
+  if len(diagnostics) == 0 {
+    t.Fatalf("expected parser diagnostics")
+  }
+
+  d := diagnostics[0]
+
+  if d.Severity != SEVERITY_ERROR {
+    t.Errorf("wrong severity. got=%q", d.Severity)
+  }
+
+  expected := Span{
+    Start: Position{Line: 2, Column: 9, Offset: 19},
+    End:   Position{Line: 2, Column: 10, Offset: 20},
+  }
+
+  if d.Span != expected {
+    t.Errorf("wrong span. expected=%+v, got=%+v", expected, d.Span)
+  }
+
+  if d.Message != "Expected next token to be = but got INT instead" {
+    t.Errorf("wrong message. got=%q", d.Message)
+  }
+}
+
+func TestRenderDiagnostic(t *testing.T) {
+  source := "let f = fn(x) {\n\tx + true\n};\nf(1);"
+
+  tests := []struct {
+    diagnostic Diagnostic
+    expected   string
+  }{
+    {
+      Diagnostic{
+        Severity: SEVERITY_ERROR,
+        Span: Span{
+          Start: Position{Line: 2, Column: 4},
+          End:   Position{Line: 2, Column: 5},
+        },
+        Message: "type mismatch: INTEGER + BOOLEAN",
+      },
+      "error: type mismatch: INTEGER + BOOLEAN\n" +
+        " --> main.monk:2:4\n" +
+        "  |\n" +
+        "2 | \tx + true\n" +
+        "  | \t  ^\n",
+    },
+    {
+      Diagnostic{
+        Severity: SEVERITY_WARNING,
+        Span: Span{
+          Start: Position{Line: 1, Column: 5},
+          End:   Position{Line: 1, Column: 6},
+        },
+        Message: "unused binding",
+        Notes:   []string{"f is never called"},
+        Help:    "remove the binding",
+      },
+      "warning: unused binding\n" +
+        " --> main.monk:1:5\n" +
+        "  |\n" +
+        "1 | let f = fn(x) {\n" +
+        "  |     ^\n" +
+        "  = note: f is never called\n" +
+        "  = help: remove the binding\n",
+    },
+    {
+      Diagnostic{
+        Severity: SEVERITY_ERROR,
+        Span: Span{
+          Start: Position{Line: 1, Column: 9},
+          End:   Position{Line: 3, Column: 2},
+        },
+        Message: "spans lines",
+      },
+      "error: spans lines\n" +
+        " --> main.monk:1:9\n" +
+        "  |\n" +
+        "1 | let f = fn(x) {\n" +
+        "  |         ^^^^^^^\n",
+    },
+    {
+      Diagnostic{Severity: SEVERITY_ERROR, Message: "no location"},
+      "error: no location\n" +
+        "--> main.monk\n",
+    },
+  }
+
+  renderer := NewRenderer("main.monk", source, false)
+
+  for _, tt := range tests {
+    var out bytes.Buffer
+
+    renderer.Render(&out, tt.diagnostic)
+
+    if out.String() != tt.expected {
+      t.Errorf("wrong rendering.\nwant=\n%s\ngot=\n%s",
+        tt.expected, out.String())
+    }
+  }
+}
+
+func TestRenderDiagnosticUnicode(t *testing.T) {
+  source := "let ñ = \"é\" + ñ"
+
+  diagnostic := Diagnostic{
+    Severity: SEVERITY_ERROR,
+    Span: Span{
+      Start: Position{Line: 1, Column: 13},
+      End:   Position{Line: 1, Column: 14},
+    },
+    Message: "type mismatch: STRING + INTEGER",
+  }
+
+  expected := "error: type mismatch: STRING + INTEGER\n" +
+    " --> main.monk:1:13\n" +
+    "  |\n" +
+    "1 | let ñ = \"é\" + ñ\n" +
+    "  |             ^\n"
+
+  var out bytes.Buffer
+
+  NewRenderer("main.monk", source, false).Render(&out, diagnostic)
+
+  if out.String() != expected {
+    t.Errorf("wrong rendering.\nwant=\n%s\ngot=\n%s", expected, out.String())
+  }
+}
+
+func TestRenderDiagnosticWithColor(t *testing.T) {
+  var out bytes.Buffer
+
+  NewRenderer("main.monk", "1 + true", true).Render(
+    &out,
+    (&Error{
+      Message:  "type mismatch: INTEGER + BOOLEAN",
+      Position: Position{Line: 1, Column: 3, Offset: 2},
+    }).Diagnostic(),
+  )
+
+  if !bytes.Contains(out.Bytes(), []byte(ansiRed)) {
+    t.Errorf("expected colored output. got=%q", out.String())
+  }
+
+  if !bytes.Contains(out.Bytes(), []byte("1 + true")) {
+    t.Errorf("expected source line in output. got=%q", out.String())
+  }
+}
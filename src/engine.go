@@ -0,0 +1,186 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "runtime/debug"
+)
+
+const (
+  ENGINE_EVAL = "eval"
+  ENGINE_VM   = "vm"
+)
+
+// DEFAULT_MAX_DEPTH is how deeply function calls may nest unless configured
+// otherwise. It is well within what the Go stack can hold, so runaway
+// recursion is reported as an error rather than crashing the process.
+const DEFAULT_MAX_DEPTH = 10000
+
+// MAX_DEPTH_LIMIT is the deepest nesting of function calls that may be
+// configured.
+const MAX_DEPTH_LIMIT = 100000
+
+// STACK_PER_CALL is the number of bytes of Go stack set aside for each call
+// the tree-walking interpreter may nest, which leaves room for calls made
+// from within deeply nested expressions.
+const STACK_PER_CALL = 64 * 1024
+
+// Options configures how an engine runs programs.
+type Options struct {
+  // Arithmetic selects how integer overflow is handled. The empty value
+  // selects ARITHMETIC_BIG.
+  Arithmetic Arithmetic
+
+  // MaxDepth bounds how deeply function calls may nest. It must be between
+  // 1 and MAX_DEPTH_LIMIT, and the zero value selects DEFAULT_MAX_DEPTH.
+  MaxDepth int
+}
+
+func (o Options) arithmetic() Arithmetic {
+  if o.Arithmetic == "" {
+    return ARITHMETIC_BIG
+  }
+
+  return o.Arithmetic
+}
+
+func (o Options) maxDepth() int {
+  if o.MaxDepth == 0 {
+    return DEFAULT_MAX_DEPTH
+  }
+
+  return o.MaxDepth
+}
+
+// Engine runs parsed programs. Each engine keeps its own state between
+// runs, so successive programs can see the bindings of earlier ones.
+type Engine interface {
+  Run(program *Program) Object
+}
+
+func NewEngine(name string, options Options) (Engine, error) {
+  arithmetic := options.arithmetic()
+
+  switch arithmetic {
+  case ARITHMETIC_BIG, ARITHMETIC_CHECKED, ARITHMETIC_WRAPPING:
+  default:
+    return nil, fmt.Errorf(
+      "unknown arithmetic %q, expected %q, %q or %q",
+      options.Arithmetic,
+      ARITHMETIC_BIG,
+      ARITHMETIC_CHECKED,
+      ARITHMETIC_WRAPPING,
+    )
+  }
+
+  if err := checkMaxDepth(options.maxDepth()); err != nil {
+    return nil, err
+  }
+
+  switch name {
+  case ENGINE_EVAL:
+    return NewInterpreter(options), nil
+  case ENGINE_VM:
+    return NewMachine(options), nil
+  default:
+    return nil, fmt.Errorf(
+      "unknown engine %q, expected %q or %q",
+      name,
+      ENGINE_EVAL,
+      ENGINE_VM,
+    )
+  }
+}
+
+// checkMaxDepth reports an error if depth is not a supported limit on the
+// nesting of function calls.
+func checkMaxDepth(depth int) error {
+  if depth < 1 || depth > MAX_DEPTH_LIMIT {
+    return fmt.Errorf(
+      "max depth must be between 1 and %d, got %d",
+      MAX_DEPTH_LIMIT,
+      depth,
+    )
+  }
+
+  return nil
+}
+
+// reserveStack raises the limit on the size of the Go stack, if need be, so
+// that the tree-walking interpreter can nest depth calls without
+// exhausting it.
+func reserveStack(depth int) {
+  size := depth * STACK_PER_CALL
+
+  if previous := debug.SetMaxStack(size); previous > size {
+    debug.SetMaxStack(previous)
+  }
+}
+
+// Interpreter runs programs by walking their syntax tree.
+type Interpreter struct {
+  env *Environment
+}
+
+func NewInterpreter(options Options) *Interpreter {
+  env := NewEnvironment()
+  env.arithmetic = options.arithmetic()
+  env.calls.maxDepth = options.maxDepth()
+  reserveStack(env.calls.maxDepth)
+  return &Interpreter{env: env}
+}
+
+func (i *Interpreter) Run(program *Program) Object {
+  return Eval(program, i.env)
+}
+
+// Machine runs programs by compiling them to bytecode and executing the
+// result on the virtual machine.
+type Machine struct {
+  arithmetic Arithmetic
+  constants  []Object
+  globals    []Object
+  maxDepth   int
+  symbols    *SymbolTable
+}
+
+func NewMachine(options Options) *Machine {
+  return &Machine{
+    arithmetic: options.arithmetic(),
+    constants:  []Object{},
+    globals:    make([]Object, GLOBALS_SIZE),
+    maxDepth:   options.maxDepth(),
+    symbols:    NewSymbolTable(),
+  }
+}
+
+func (m *Machine) Run(program *Program) Object {
+  compiler := NewCompilerWithState(m.symbols, m.constants)
+
+  if err := compiler.Compile(program); err != nil {
+    return toErrorObject(err)
+  }
+
+  bytecode := compiler.Bytecode()
+  m.constants = bytecode.Constants
+
+  vm := NewVMWithGlobals(bytecode, m.globals)
+  vm.arithmetic = m.arithmetic
+  vm.maxDepth = m.maxDepth
+
+  if err := vm.Run(); err != nil {
+    return toErrorObject(err)
+  }
+
+  return vm.Result()
+}
+
+func toErrorObject(err error) *Error {
+  var obj *Error
+
+  if errors.As(err, &obj) {
+    return obj
+  }
+
+  return &Error{Message: err.Error()}
+}
@@ -1,20 +1,28 @@
 package main
 
 type Environment struct {
-  store map[string]Object
-  outer *Environment
+  store      map[string]Object
+  constants  map[string]Node
+  outer      *Environment
+  arithmetic Arithmetic
+  calls      *CallStack
 }
 
 func NewEnvironment() *Environment {
   return &Environment{
-    store: make(map[string]Object),
-    outer: nil,
+    store:      make(map[string]Object),
+    constants:  make(map[string]Node),
+    outer:      nil,
+    arithmetic: ARITHMETIC_BIG,
+    calls:      &CallStack{maxDepth: DEFAULT_MAX_DEPTH},
   }
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
   env := NewEnvironment()
   env.outer = outer
+  env.arithmetic = outer.arithmetic
+  env.calls = outer.calls
   return env
 }
 
@@ -32,3 +40,77 @@ func (e *Environment) Set(name string, val Object) Object {
   e.store[name] = val
   return val
 }
+
+// Declare binds name in this environment as declaration, refusing to
+// replace a constant bound in it by another declaration. The declaration
+// of a constant may run again, as it does in the body of a loop, which
+// shares the environment enclosing it. Enclosed environments may still
+// shadow the constant.
+func (e *Environment) Declare(
+  declaration Node,
+  name string,
+  val Object,
+  constant bool,
+) *Error {
+  if previous, ok := e.constants[name]; ok && previous != declaration {
+    return newError("redeclaration of constant: %s", name)
+  }
+
+  e.store[name] = val
+
+  if constant {
+    e.constants[name] = declaration
+  }
+
+  return nil
+}
+
+// Assign rebinds name in the innermost environment that defines it,
+// refusing to rebind a constant.
+func (e *Environment) Assign(name string, val Object) *Error {
+  for env := e; env != nil; env = env.outer {
+    if _, ok := env.store[name]; ok {
+      if _, ok := env.constants[name]; ok {
+        return newError("assignment to constant: %s", name)
+      }
+
+      env.store[name] = val
+
+      return nil
+    }
+  }
+
+  return newUndeclaredError(name)
+}
+
+// CallStack records the function calls in progress. It is shared by every
+// environment created while evaluating a program, since calls nest
+// dynamically rather than lexically.
+type CallStack struct {
+  frames   []StackFrame
+  maxDepth int
+}
+
+// full reports whether another call would nest deeper than allowed.
+func (c *CallStack) full() bool {
+  return len(c.frames) >= c.maxDepth
+}
+
+func (c *CallStack) push(frame StackFrame) {
+  c.frames = append(c.frames, frame)
+}
+
+func (c *CallStack) pop() {
+  c.frames = c.frames[:len(c.frames)-1]
+}
+
+// Trace returns the calls in progress, innermost first.
+func (c *CallStack) Trace() []StackFrame {
+  trace := make([]StackFrame, len(c.frames))
+
+  for i, frame := range c.frames {
+    trace[len(c.frames)-1-i] = frame
+  }
+
+  return trace
+}
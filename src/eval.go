@@ -2,15 +2,33 @@ package main
 
 import (
   "fmt"
+  "math"
+  "math/big"
+  "sort"
+  "strings"
 )
 
 var (
-  NULL_LIT  = &Null{}
-  TRUE_LIT  = &Boolean{Value: true}
-  FALSE_LIT = &Boolean{Value: false}
+  NULL_LIT        = &Null{}
+  TRUE_LIT        = &Boolean{Value: true}
+  FALSE_LIT       = &Boolean{Value: false}
+  BREAK_SIGNAL    = &Break{}
+  CONTINUE_SIGNAL = &Continue{}
 )
 
+// Eval evaluates node in env. Errors raised while evaluating node are
+// stamped with the position of the innermost node that produced them.
 func Eval(node Node, env *Environment) Object {
+  result := evalNode(node, env)
+
+  if err, ok := result.(*Error); ok && !err.Position.IsValid() {
+    err.Position = node.Pos()
+  }
+
+  return result
+}
+
+func evalNode(node Node, env *Environment) Object {
   switch node := node.(type) {
   case *Program:
     return evalProgram(node, env)
@@ -22,53 +40,110 @@ func Eval(node Node, env *Environment) Object {
     return Eval(node.Expression, env)
   case *IfExpression:
     return evalIfExpression(node, env)
+  case *WhileStatement:
+    return evalWhileStatement(node, env)
+  case *ForStatement:
+    return evalForStatement(node, env)
+  case *BreakStatement:
+    return BREAK_SIGNAL
+  case *ContinueStatement:
+    return CONTINUE_SIGNAL
   case *InfixExpression:
+    if node.Operator == AND || node.Operator == OR {
+      return evalLogicalExpression(node, env)
+    }
     left := Eval(node.Left, env)
-    if isError(left) {
+    if isAbrupt(left) {
       return left
     }
     right := Eval(node.Right, env)
-    if isError(right) {
+    if isAbrupt(right) {
       return right
     }
-    return evalInfixExpression(node.Operator, left, right)
+    return evalInfixExpression(node.Operator, left, right, env.arithmetic)
+  case *AssignExpression:
+    return evalAssignExpression(node, env)
+  case *FloatLiteral:
+    return &Float{Value: node.Value}
   case *IntegerLiteral:
+    if node.Big != nil {
+      return fitInteger(node.Big, env.arithmetic)
+    }
     return &Integer{Value: node.Value}
+  case *StringLiteral:
+    return &String{Value: node.Value}
   case *PrefixExpression:
     right := Eval(node.Right, env)
-    if isError(right) {
+    if isAbrupt(right) {
       return right
     }
-    return evalPrefixExpression(node.Operator, right)
+    return evalPrefixExpression(node.Operator, right, env.arithmetic)
   case *Identifier:
     return evalIdentifier(node, env)
   case *LetStatement:
     val := Eval(node.Value, env)
-    if isError(val) {
+    if isAbrupt(val) {
       return val
     }
-    env.Set(node.Name.Value, val)
+    err := env.Declare(node, node.Name.Value, val, node.Constant)
+    if err != nil {
+      return err
+    }
     return NULL_LIT
   case *ReturnStatement:
     val := Eval(node.ReturnValue, env)
-    if isError(val) {
+    if isAbrupt(val) {
       return val
     }
     return &ReturnValue{Value: val}
   case *FunctionLiteral:
     params := node.Parameters
     body := node.Body
-    return &Function{Parameters: params, Body: body, Env: env}
+    return &Function{
+      Name:       node.Name,
+      Parameters: params,
+      Defaults:   node.Defaults,
+      Rest:       node.Rest,
+      Body:       body,
+      Env:        env,
+    }
   case *CallExpression:
     function := Eval(node.Function, env)
-    if isError(function) {
+    if isAbrupt(function) {
       return function
     }
     args := evalExpressions(node.Arguments, env)
-    if len(args) == 1 && isError(args[0]) {
+    if len(args) == 1 && isAbrupt(args[0]) {
       return args[0]
     }
-    return applyFunction(function, args)
+    return applyFunction(function, args, env, node.Pos())
+  case *ArrayLiteral:
+    elements := evalExpressions(node.Elements, env)
+    if len(elements) == 1 && isAbrupt(elements[0]) {
+      return elements[0]
+    }
+    return &Array{Elements: elements}
+  case *IndexExpression:
+    left := Eval(node.Left, env)
+    if isAbrupt(left) {
+      return left
+    }
+    index := Eval(node.Index, env)
+    if isAbrupt(index) {
+      return index
+    }
+    return evalIndexExpression(left, index)
+  case *HashLiteral:
+    return evalHashLiteral(node, env)
+  case *SpreadExpression:
+    value := Eval(node.Value, env)
+    if isAbrupt(value) {
+      return value
+    }
+    if value.Type() != ARRAY_OBJ {
+      return newError("spread argument must be ARRAY, got %s", value.Type())
+    }
+    return value
   }
 
   return nil
@@ -98,8 +173,8 @@ func evalBlockStatement(block *BlockStatement, env *Environment) Object {
     result = Eval(statement, env)
 
     if result != nil {
-      rt := result.Type()
-      if rt == RETURN_VALUE_OBJ || rt == ERROR_OBJ {
+      switch result.Type() {
+      case RETURN_VALUE_OBJ, ERROR_OBJ, BREAK_OBJ, CONTINUE_OBJ:
         return result
       }
     }
@@ -108,6 +183,92 @@ func evalBlockStatement(block *BlockStatement, env *Environment) Object {
   return result
 }
 
+func evalWhileStatement(node *WhileStatement, env *Environment) Object {
+  for {
+    condition := Eval(node.Condition, env)
+    if isAbrupt(condition) {
+      return condition
+    }
+
+    if !isTruthy(condition) {
+      return NULL_LIT
+    }
+
+    if result, done := evalLoopBody(node.Body, env); done {
+      return result
+    }
+  }
+}
+
+func evalForStatement(node *ForStatement, env *Environment) Object {
+  iterable := Eval(node.Iterable, env)
+  if isAbrupt(iterable) {
+    return iterable
+  }
+
+  elements, err := iterableElements(iterable)
+  if err != nil {
+    return err
+  }
+
+  for _, element := range elements {
+    err := env.Declare(node, node.Variable.Value, element, false)
+    if err != nil {
+      return err
+    }
+
+    if result, done := evalLoopBody(node.Body, env); done {
+      return result
+    }
+  }
+
+  return NULL_LIT
+}
+
+// evalLoopBody runs one iteration of a loop, reporting whether the loop is
+// done along with the value it is done with.
+func evalLoopBody(body *BlockStatement, env *Environment) (Object, bool) {
+  switch result := Eval(body, env).(type) {
+  case *Break:
+    return NULL_LIT, true
+  case *ReturnValue, *Error:
+    return result, true
+  default:
+    return nil, false
+  }
+}
+
+// iterableElements returns the values a `for` loop steps through: the
+// elements of an array, the characters of a string, or the keys of a hash
+// sorted by how they print.
+func iterableElements(iterable Object) ([]Object, *Error) {
+  switch iterable := iterable.(type) {
+  case *Array:
+    return iterable.Elements, nil
+  case *String:
+    elements := []Object{}
+    for _, ch := range iterable.Value {
+      elements = append(elements, &String{Value: string(ch)})
+    }
+    return elements, nil
+  case *Hash:
+    pairs := make([]HashPair, 0, len(iterable.Pairs))
+    for _, pair := range iterable.Pairs {
+      pairs = append(pairs, pair)
+    }
+    sort.Slice(pairs, func(i, j int) bool {
+      return pairs[i].Key.Inspect() < pairs[j].Key.Inspect()
+    })
+    elements := make([]Object, len(pairs))
+    for i, pair := range pairs {
+      elements[i] = pair.Key
+    }
+    return elements, nil
+  default:
+    return nil, newError("cannot iterate over %s", iterable.Type())
+  }
+}
+
 func evalStatements(statements []Statement, env *Environment) Object {
   var result Object
 
@@ -130,12 +291,30 @@ func nativeBoolToBooleanObject(input bool) *Boolean {
   }
 }
 
-func evalPrefixExpression(operator string, right Object) Object {
+func evalPrefixExpression(
+  operator string,
+  right Object,
+  arithmetic Arithmetic,
+) Object {
+  right = fitOperand(right, arithmetic)
+  if isError(right) {
+    return right
+  }
+
   switch operator {
   case "!":
     return evalBangOperatorExpression(right)
   case "-":
-    return evalMinusPrefixOperatorExpression(right)
+    return evalMinusPrefixOperatorExpression(right, arithmetic)
+  case "~":
+    switch right := right.(type) {
+    case *Integer:
+      return &Integer{Value: ^right.Value}
+    case *BigInt:
+      return newInteger(new(big.Int).Not(right.Value))
+    default:
+      return newError("unknown operator: ~%s", right.Type())
+    }
   default:
     return newError("unknown operator: %s%s", operator, right.Type())
   }
@@ -154,20 +333,55 @@ func evalBangOperatorExpression(right Object) Object {
   }
 }
 
-func evalMinusPrefixOperatorExpression(right Object) Object {
-  if right.Type() != INTEGER_OBJ {
+func evalMinusPrefixOperatorExpression(
+  right Object,
+  arithmetic Arithmetic,
+) Object {
+  switch right := right.(type) {
+  case *Float:
+    return &Float{Value: -right.Value}
+  case *BigInt:
+    return newInteger(new(big.Int).Neg(right.Value))
+  case *Integer:
+    if right.Value != math.MinInt64 {
+      return &Integer{Value: -right.Value}
+    }
+
+    switch arithmetic {
+    case ARITHMETIC_BIG:
+      return newInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+    case ARITHMETIC_CHECKED:
+      return newError("integer overflow: -(%d)", right.Value)
+    default:
+      return right
+    }
+  default:
     return newError("unknown operator: -%s", right.Type())
   }
-
-  value := right.(*Integer).Value
-
-  return &Integer{Value: -value}
 }
 
-func evalInfixExpression(operator string, left, right Object) Object {
+func evalInfixExpression(
+  operator string,
+  left, right Object,
+  arithmetic Arithmetic,
+) Object {
+  left, right = fitOperand(left, arithmetic), fitOperand(right, arithmetic)
+  if isError(left) {
+    return left
+  }
+  if isError(right) {
+    return right
+  }
+
   switch {
   case left.Type() == INTEGER_OBJ && right.Type() == INTEGER_OBJ:
-    return evalIntegerInfixExpression(operator, left, right)
+    return evalIntegerInfixExpression(operator, left, right, arithmetic)
+  case isInteger(left) && isInteger(right):
+    return evalBigIntInfixExpression(operator, left, right)
+  case isNumber(left) && isNumber(right):
+    return evalFloatInfixExpression(operator, left, right)
+  case left.Type() == STRING_OBJ && right.Type() == STRING_OBJ:
+    return evalStringInfixExpression(operator, left, right)
   case operator == "==":
     return nativeBoolToBooleanObject(left == right)
   case operator == "!=":
@@ -181,18 +395,238 @@ func evalInfixExpression(operator string, left, right Object) Object {
   }
 }
 
-func evalIntegerInfixExpression(operator string, left, right Object) Object {
+func evalIntegerInfixExpression(
+  operator string,
+  left, right Object,
+  arithmetic Arithmetic,
+) Object {
   leftVal := left.(*Integer).Value
   rightVal := right.(*Integer).Value
   switch operator {
+  case "+", "-", "*", "/", "%", "**", "<<":
+    if operator == "/" && rightVal == 0 {
+      return newError("division by zero")
+    }
+    if operator == "%" && rightVal == 0 {
+      return newError("modulo by zero")
+    }
+    if operator == "**" && rightVal < 0 {
+      return newError("negative exponent: %d ** %d", leftVal, rightVal)
+    }
+    if operator == "<<" && rightVal < 0 {
+      return newError("negative shift count: %d << %d", leftVal, rightVal)
+    }
+    value, overflow := integerArithmetic(operator, leftVal, rightVal)
+    if overflow && arithmetic == ARITHMETIC_BIG {
+      return evalBigIntInfixExpression(operator, left, right)
+    }
+    if overflow && arithmetic == ARITHMETIC_CHECKED {
+      return newError("integer overflow: %d %s %d",
+        leftVal, operator, rightVal)
+    }
+    return &Integer{Value: value}
+  case "==":
+    return nativeBoolToBooleanObject(leftVal == rightVal)
+  case "!=":
+    return nativeBoolToBooleanObject(leftVal != rightVal)
+  case "<":
+    return nativeBoolToBooleanObject(leftVal < rightVal)
+  case ">":
+    return nativeBoolToBooleanObject(leftVal > rightVal)
+  case ">>":
+    if rightVal < 0 {
+      return newError("negative shift count: %d >> %d", leftVal, rightVal)
+    }
+    return &Integer{Value: leftVal >> uint64(rightVal)}
+  case "&":
+    return &Integer{Value: leftVal & rightVal}
+  case "|":
+    return &Integer{Value: leftVal | rightVal}
+  case "^":
+    return &Integer{Value: leftVal ^ rightVal}
+  case "<=":
+    return nativeBoolToBooleanObject(leftVal <= rightVal)
+  case ">=":
+    return nativeBoolToBooleanObject(leftVal >= rightVal)
+  default:
+    return newError("unknown operator: %s %s %s",
+      left.Type(), operator, right.Type())
+  }
+}
+
+// evalBigIntInfixExpression evaluates an operation on two integers with
+// arbitrary precision. It is used when either operand is a BigInt, or when
+// the operation overflows an Integer.
+func evalBigIntInfixExpression(operator string, left, right Object) Object {
+  leftVal := bigValue(left)
+  rightVal := bigValue(right)
+  result := new(big.Int)
+  switch operator {
   case "+":
-    return &Integer{Value: leftVal + rightVal}
+    return newInteger(result.Add(leftVal, rightVal))
   case "-":
-    return &Integer{Value: leftVal - rightVal}
+    return newInteger(result.Sub(leftVal, rightVal))
   case "*":
-    return &Integer{Value: leftVal * rightVal}
+    return newInteger(result.Mul(leftVal, rightVal))
   case "/":
-    return &Integer{Value: leftVal / rightVal}
+    if rightVal.Sign() == 0 {
+      return newError("division by zero")
+    }
+    return newInteger(result.Quo(leftVal, rightVal))
+  case "%":
+    if rightVal.Sign() == 0 {
+      return newError("modulo by zero")
+    }
+    return newInteger(result.Rem(leftVal, rightVal))
+  case "**":
+    if rightVal.Sign() < 0 {
+      return newError("negative exponent: %s ** %s", leftVal, rightVal)
+    }
+    if powerTooLarge(leftVal, rightVal) {
+      return newTooLargeError()
+    }
+    return newInteger(result.Exp(leftVal, rightVal, nil))
+  case "<<":
+    if rightVal.Sign() < 0 {
+      return newError("negative shift count: %s << %s", leftVal, rightVal)
+    }
+    if leftVal.Sign() == 0 {
+      return newInteger(result)
+    }
+    if !rightVal.IsInt64() ||
+      rightVal.Int64() > MAX_INTEGER_BITS-int64(leftVal.BitLen()) {
+      return newTooLargeError()
+    }
+    return newInteger(result.Lsh(leftVal, uint(rightVal.Int64())))
+  case ">>":
+    if rightVal.Sign() < 0 {
+      return newError("negative shift count: %s >> %s", leftVal, rightVal)
+    }
+    if !rightVal.IsInt64() {
+      return newError("shift count too large: %s >> %s", leftVal, rightVal)
+    }
+    return newInteger(result.Rsh(leftVal, uint(rightVal.Int64())))
+  case "&":
+    return newInteger(result.And(leftVal, rightVal))
+  case "|":
+    return newInteger(result.Or(leftVal, rightVal))
+  case "^":
+    return newInteger(result.Xor(leftVal, rightVal))
+  case "==":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+  case "!=":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+  case "<":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+  case ">":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+  case "<=":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+  case ">=":
+    return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+  default:
+    return newError("unknown operator: %s %s %s",
+      left.Type(), operator, right.Type())
+  }
+}
+
+// powerTooLarge reports whether base ** exponent certainly has more than
+// MAX_INTEGER_BITS bits, so that it need not be computed to find out.
+func powerTooLarge(base, exponent *big.Int) bool {
+  bits := base.BitLen()
+
+  if bits <= 1 {
+    return false
+  }
+
+  // The power has at least (bits - 1) * exponent + 1 bits.
+  return !exponent.IsInt64() ||
+    exponent.Int64() > MAX_INTEGER_BITS ||
+    int64(bits-1)*exponent.Int64() >= MAX_INTEGER_BITS
+}
+
+// newInteger returns value as an Integer when it fits in one, and as a
+// BigInt otherwise, unless it is too large to represent.
+func newInteger(value *big.Int) Object {
+  if value.IsInt64() {
+    return &Integer{Value: value.Int64()}
+  }
+
+  if value.BitLen() > MAX_INTEGER_BITS {
+    return newTooLargeError()
+  }
+
+  return &BigInt{Value: value}
+}
+
+func newTooLargeError() *Error {
+  return newError("integer too large: more than %d bits", MAX_INTEGER_BITS)
+}
+
+// fitInteger returns value, which may not fit in 64 bits, as arithmetic
+// dictates: continued as a BigInt, reported as an overflow, or wrapped.
+func fitInteger(value *big.Int, arithmetic Arithmetic) Object {
+  switch {
+  case value.IsInt64():
+    return &Integer{Value: value.Int64()}
+  case arithmetic == ARITHMETIC_CHECKED:
+    return newError("integer overflow: %s", value)
+  case arithmetic == ARITHMETIC_WRAPPING:
+    return &Integer{Value: wrapInteger(value)}
+  default:
+    return newInteger(value)
+  }
+}
+
+// fitOperand brings a BigInt operand into 64 bits when arithmetic does not
+// allow arbitrary precision. Literals and results follow the arithmetic as
+// they are made, so such operands only come from builtins like `int`.
+func fitOperand(obj Object, arithmetic Arithmetic) Object {
+  if integer, ok := obj.(*BigInt); ok {
+    return fitInteger(integer.Value, arithmetic)
+  }
+
+  return obj
+}
+
+func isInteger(obj Object) bool {
+  return obj.Type() == INTEGER_OBJ || obj.Type() == BIGINT_OBJ
+}
+
+// bigValue returns the value of an integer as a big.Int, which must not be
+// modified.
+func bigValue(obj Object) *big.Int {
+  if integer, ok := obj.(*Integer); ok {
+    return big.NewInt(integer.Value)
+  }
+
+  return obj.(*BigInt).Value
+}
+
+// evalFloatInfixExpression evaluates an operation on two numbers, at least
+// one of which is a float. Integers are promoted to floats beforehand.
+func evalFloatInfixExpression(operator string, left, right Object) Object {
+  leftVal := floatValue(left)
+  rightVal := floatValue(right)
+  switch operator {
+  case "+":
+    return &Float{Value: leftVal + rightVal}
+  case "-":
+    return &Float{Value: leftVal - rightVal}
+  case "*":
+    return &Float{Value: leftVal * rightVal}
+  case "/":
+    if rightVal == 0 {
+      return newError("division by zero")
+    }
+    return &Float{Value: leftVal / rightVal}
+  case "%":
+    if rightVal == 0 {
+      return newError("modulo by zero")
+    }
+    return &Float{Value: math.Mod(leftVal, rightVal)}
+  case "**":
+    return &Float{Value: math.Pow(leftVal, rightVal)}
   case "==":
     return nativeBoolToBooleanObject(leftVal == rightVal)
   case "!=":
@@ -201,15 +635,72 @@ func evalIntegerInfixExpression(operator string, left, right Object) Object {
     return nativeBoolToBooleanObject(leftVal < rightVal)
   case ">":
     return nativeBoolToBooleanObject(leftVal > rightVal)
+  case "<=":
+    return nativeBoolToBooleanObject(leftVal <= rightVal)
+  case ">=":
+    return nativeBoolToBooleanObject(leftVal >= rightVal)
   default:
     return newError("unknown operator: %s %s %s",
       left.Type(), operator, right.Type())
   }
 }
 
+func isNumber(obj Object) bool {
+  return isInteger(obj) || obj.Type() == FLOAT_OBJ
+}
+
+// floatValue returns the value of a number as a float.
+func floatValue(obj Object) float64 {
+  switch obj := obj.(type) {
+  case *Integer:
+    return float64(obj.Value)
+  case *BigInt:
+    value, _ := new(big.Float).SetInt(obj.Value).Float64()
+    return value
+  default:
+    return obj.(*Float).Value
+  }
+}
+
+func evalStringInfixExpression(operator string, left, right Object) Object {
+  leftVal := left.(*String).Value
+  rightVal := right.(*String).Value
+  switch operator {
+  case "+":
+    return &String{Value: leftVal + rightVal}
+  case "==":
+    return nativeBoolToBooleanObject(leftVal == rightVal)
+  case "!=":
+    return nativeBoolToBooleanObject(leftVal != rightVal)
+  default:
+    return newError("unknown operator: %s %s %s",
+      left.Type(), operator, right.Type())
+  }
+}
+
+// evalLogicalExpression evaluates `&&` and `||`, which only evaluate their
+// right operand when the left one does not decide the result.
+func evalLogicalExpression(node *InfixExpression, env *Environment) Object {
+  left := Eval(node.Left, env)
+  if isAbrupt(left) {
+    return left
+  }
+
+  if isTruthy(left) == (node.Operator == OR) {
+    return nativeBoolToBooleanObject(isTruthy(left))
+  }
+
+  right := Eval(node.Right, env)
+  if isAbrupt(right) {
+    return right
+  }
+
+  return nativeBoolToBooleanObject(isTruthy(right))
+}
+
 func evalIfExpression(ie *IfExpression, env *Environment) Object {
   condition := Eval(ie.Condition, env)
-  if isError(condition) {
+  if isAbrupt(condition) {
     return condition
   }
 
@@ -223,46 +714,224 @@ func evalIfExpression(ie *IfExpression, env *Environment) Object {
 }
 
 func evalIdentifier(node *Identifier, env *Environment) Object {
-  val, ok := env.Get(node.Value)
+  if val, ok := env.Get(node.Value); ok {
+    return val
+  }
+
+  if builtin, ok := builtins[node.Value]; ok {
+    return builtin
+  }
+
+  return newError("identifier not found: %s", node.Value)
+}
+
+// evalAssignExpression rebinds a variable that is already defined, so that
+// closures can update the variables they capture. Compound assignments read
+// the variable before evaluating the new value.
+func evalAssignExpression(node *AssignExpression, env *Environment) Object {
+  name := node.Name.Value
+
+  current, ok := env.Get(name)
+  if !ok {
+    return newUndeclaredError(name)
+  }
+
+  value := Eval(node.Value, env)
+  if isAbrupt(value) {
+    return value
+  }
+
+  if operator := strings.TrimSuffix(node.Operator, "="); operator != "" {
+    value = evalInfixExpression(operator, current, value, env.arithmetic)
+    if isAbrupt(value) {
+      return value
+    }
+  }
+
+  if err := env.Assign(name, value); err != nil {
+    return err
+  }
+
+  return value
+}
+
+func newUndeclaredError(name string) *Error {
+  return newError("assignment to undeclared identifier: %s", name)
+}
+
+func evalIndexExpression(left, index Object) Object {
+  switch {
+  case left.Type() == ARRAY_OBJ && index.Type() == INTEGER_OBJ:
+    return evalArrayIndexExpression(left, index)
+  case left.Type() == HASH_OBJ:
+    return evalHashIndexExpression(left, index)
+  default:
+    return newError("index operator not supported: %s", left.Type())
+  }
+}
+
+func evalArrayIndexExpression(array, index Object) Object {
+  elements := array.(*Array).Elements
+  idx := index.(*Integer).Value
+
+  if idx < 0 || idx >= int64(len(elements)) {
+    return NULL_LIT
+  }
+
+  return elements[idx]
+}
+
+func evalHashIndexExpression(hash, index Object) Object {
+  key, ok := index.(Hashable)
   if !ok {
-    return newError("identifier not found: %s", node.Value)
+    return newError("unusable as hash key: %s", index.Type())
   }
-  return val
+
+  pair, ok := hash.(*Hash).Pairs[key.HashKey()]
+  if !ok {
+    return NULL_LIT
+  }
+
+  return pair.Value
 }
 
+func evalHashLiteral(node *HashLiteral, env *Environment) Object {
+  pairs := make(map[HashKey]HashPair)
+
+  for i, keyNode := range node.Keys {
+    key := Eval(keyNode, env)
+    if isAbrupt(key) {
+      return key
+    }
+
+    hashKey, ok := key.(Hashable)
+    if !ok {
+      return newError("unusable as hash key: %s", key.Type())
+    }
+
+    value := Eval(node.Values[i], env)
+    if isAbrupt(value) {
+      return value
+    }
+
+    pairs[hashKey.HashKey()] = HashPair{Key: key, Value: value}
+  }
+
+  return &Hash{Pairs: pairs}
+}
+
+// evalExpressions evaluates a list of expressions, expanding the elements
+// of any spread arrays in place.
 func evalExpressions(exps []Expression, env *Environment) []Object {
   var result []Object
 
   for _, e := range exps {
     evaluated := Eval(e, env)
-    if isError(evaluated) {
+    if isAbrupt(evaluated) {
       return []Object{evaluated}
     }
+    if _, ok := e.(*SpreadExpression); ok {
+      result = append(result, evaluated.(*Array).Elements...)
+      continue
+    }
     result = append(result, evaluated)
   }
 
   return result
 }
 
-func applyFunction(fn Object, args []Object) Object {
-  function, ok := fn.(*Function)
-  if !ok {
+// applyFunction calls fn from the call site at position. Errors raised
+// during the call carry a trace of the calls in progress when they were
+// raised.
+func applyFunction(
+  fn Object,
+  args []Object,
+  env *Environment,
+  position Position,
+) Object {
+  var result Object
+
+  switch fn := fn.(type) {
+  case *Function:
+    if !fn.Arity().Accepts(len(args)) {
+      return newArityError(fn.Name, fn.Arity(), len(args))
+    }
+    if env.calls.full() {
+      return newRecursionError(fn.Name)
+    }
+    env.calls.push(StackFrame{Function: fn.Name, Position: position})
+    defer env.calls.pop()
+    if extendedEnv, err := extendFunctionEnv(fn, args); err != nil {
+      result = err
+    } else {
+      result = unwrapReturnValue(Eval(fn.Body, extendedEnv))
+    }
+  case *Builtin:
+    if !fn.Arity.Accepts(len(args)) {
+      return newArityError(fn.Name, fn.Arity, len(args))
+    }
+    env.calls.push(StackFrame{Function: fn.Name, Position: position})
+    defer env.calls.pop()
+    result = fn.Fn(args...)
+  default:
     return newError("not a function: %s", fn.Type())
   }
 
-  extendedEnv := extendFunctionEnv(function, args)
-  evaluated := Eval(function.Body, extendedEnv)
-  return unwrapReturnValue(evaluated)
+  if err, ok := result.(*Error); ok && err.Trace == nil {
+    err.Trace = env.calls.Trace()
+  }
+
+  return result
+}
+
+func newArityError(name string, arity Arity, count int) *Error {
+  return newError(
+    "wrong number of arguments to %s: want=%s, got=%d",
+    describeFunction(name),
+    arity,
+    count,
+  )
+}
+
+func newRecursionError(name string) *Error {
+  return newError(
+    "maximum recursion depth exceeded in %s",
+    describeFunction(name),
+  )
 }
 
-func extendFunctionEnv(fn *Function, args []Object) *Environment {
+// extendFunctionEnv binds the parameters of fn to args. Parameters the
+// caller left out take their default values, evaluated in the environment
+// fn was defined in, and a rest parameter collects any remaining arguments
+// into an array.
+func extendFunctionEnv(fn *Function, args []Object) (*Environment, Object) {
   env := NewEnclosedEnvironment(fn.Env)
 
   for paramIdx, param := range fn.Parameters {
-    env.Set(param.Value, args[paramIdx])
+    if paramIdx < len(args) {
+      env.Set(param.Value, args[paramIdx])
+      continue
+    }
+
+    value := Eval(fn.Defaults[param.Value], fn.Env)
+    if isError(value) {
+      return nil, value
+    }
+
+    env.Set(param.Value, value)
   }
 
-  return env
+  if fn.Rest != nil {
+    rest := []Object{}
+
+    if len(args) > len(fn.Parameters) {
+      rest = append(rest, args[len(fn.Parameters):]...)
+    }
+
+    env.Set(fn.Rest.Value, &Array{Elements: rest})
+  }
+
+  return env, nil
 }
 
 func unwrapReturnValue(obj Object) Object {
@@ -290,6 +959,18 @@ func newError(format string, a ...interface{}) *Error {
   return &Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// isAbrupt reports whether obj ends evaluation early: an error, or the
+// signal of a `return`, `break` or `continue` statement. Like errors, the
+// signals pass straight through any expression that produces them.
+func isAbrupt(obj Object) bool {
+  switch obj.(type) {
+  case *Error, *ReturnValue, *Break, *Continue:
+    return true
+  default:
+    return false
+  }
+}
+
 func isError(obj Object) bool {
   if obj != nil {
     return obj.Type() == ERROR_OBJ
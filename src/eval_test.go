@@ -1,6 +1,7 @@
 package main
 
 import (
+  "math"
   "testing"
 )
 
@@ -12,6 +13,11 @@ func TestEvalIntegerExpression(t *testing.T) {
     {"5", 5},
     {"10", 10},
     {"9000", 9000},
+    {"1_000_000", 1000000},
+    {"0xff", 255},
+    {"0o17", 15},
+    {"017", 15},
+    {"0b101", 5},
     {"-5", -5},
     {"-10", -10},
     {"5 + 5 + 5 + 5 - 10", 10},
@@ -25,6 +31,30 @@ func TestEvalIntegerExpression(t *testing.T) {
     {"3 * 3 * 3 + 10", 37},
     {"3 * (3 * 3) + 10", 37},
     {"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+    {"7 % 3", 1},
+    {"-7 % 3", -1},
+    {"2 + 10 % 4 * 3", 8},
+    {"2 ** 10", 1024},
+    {"2 ** 0", 1},
+    {"0 ** 0", 1},
+    {"2 ** 3 ** 2", 512},
+    {"(2 ** 3) ** 2", 64},
+    {"3 * 2 ** 2", 12},
+    {"-2 ** 3", -8},
+    {"(-2) ** 63", math.MinInt64},
+    {"1 ** 9223372036854775807", 1},
+    {"12 & 10", 8},
+    {"12 | 10", 14},
+    {"12 ^ 10", 6},
+    {"~0", -1},
+    {"~5", -6},
+    {"1 << 4", 16},
+    {"256 >> 4", 16},
+    {"-16 >> 2", -4},
+    {"1 >> 64", 0},
+    {"-1 << 63", math.MinInt64},
+    {"4 | 2 ^ 6 & 3", 4},
+    {"1 << 2 + 1", 8},
   }
 
   for _, tt := range tests {
@@ -32,6 +62,58 @@ func TestEvalIntegerExpression(t *testing.T) {
   }
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"1.5", 1.5},
+    {"-2.5", -2.5},
+    {"1.5e3", 1500.0},
+    {"0.1 + 0.2", 0.30000000000000004},
+    {"1.5 * 2", 3.0},
+    {"2 * 1.5", 3.0},
+    {"7 / 2.0", 3.5},
+    {"7 / 2", 3},
+    {"(1 + 2 + 3 + 4) / 4.0", 2.5},
+    {"5.5 % 2", 1.5},
+    {"2 ** 0.5", math.Sqrt2},
+    {"2.0 ** -1", 0.5},
+    {"1.5 < 2", true},
+    {"2 >= 2.0", true},
+    {"1 == 1.0", true},
+    {"0.5 != 0.5", false},
+    {"1.0 / 0", "division by zero"},
+    {"1 % 0.0", "modulo by zero"},
+    {"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+    {`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case float64:
+      testFloatObject(t, evaluated, expected)
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case bool:
+      testBooleanObject(t, evaluated, expected)
+    case string:
+      errObj, ok := evaluated.(*Error)
+      if !ok {
+        t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if errObj.Message != expected {
+        t.Errorf("wrong error message. expected=%q, got=%q",
+          expected, errObj.Message)
+      }
+    }
+  }
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
   tests := []struct {
     input    string
@@ -39,6 +121,13 @@ func TestEvalBooleanExpression(t *testing.T) {
   }{
     {"true", true},
     {"false", false},
+    {"1 <= 2", true},
+    {"2 <= 2", true},
+    {"3 <= 2", false},
+    {"1 >= 2", false},
+    {"2 >= 2", true},
+    {"3 >= 2", true},
+    {"1 + 1 >= 2 == true", true},
   }
 
   for _, tt := range tests {
@@ -83,6 +172,42 @@ func TestBangOperator(t *testing.T) {
   }
 }
 
+func TestStringLiteral(t *testing.T) {
+  testStringObject(t, testEval(`"Hello World!"`), "Hello World!")
+}
+
+func TestStringConcatenation(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {`"Hello" + " " + "World!"`, "Hello World!"},
+    {`"line\n" + "\u{2713}"`, "line\n\u2713"},
+    {`let greet = fn(name) { "Hi, " + name }; greet("monk")`, "Hi, monk"},
+  }
+
+  for _, tt := range tests {
+    testStringObject(t, testEval(tt.input), tt.expected)
+  }
+}
+
+func TestStringComparison(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected bool
+  }{
+    {`"a" == "a"`, true},
+    {`"a" == "b"`, false},
+    {`"a" != "b"`, true},
+    {`"a" != "a"`, false},
+    {`"a" + "b" == "ab"`, true},
+  }
+
+  for _, tt := range tests {
+    testBooleanObject(t, testEval(tt.input), tt.expected)
+  }
+}
+
 func TestIfElseExpressions(t *testing.T) {
   tests := []struct {
     input    string
@@ -95,6 +220,7 @@ func TestIfElseExpressions(t *testing.T) {
     {"if (1 > 2) { 10 }", nil},
     {"if (1 > 2) { 10 } else { 20 }", 20},
     {"if (1 < 2) { 10 } else { 20 }", 10},
+    {"if (false) { x } else { 1 }", 1},
   }
 
   for _, tt := range tests {
@@ -117,6 +243,7 @@ func TestEvalLetStatements(t *testing.T) {
     {"let a = 5 * 5; a;", 25},
     {"let a = 5; let b = a; b;", 5},
     {"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+    {"let größe = 5; let 名前 = größe * 2; 名前;", 10},
   }
 
   for _, tt := range tests {
@@ -192,6 +319,90 @@ if (10 > 1) {
       "foobar",
       "identifier not found: foobar",
     },
+    {
+      "let f = fn() { g() }; f(); let g = fn() { 1 };",
+      "identifier not found: g",
+    },
+    {
+      "if (false) { let x = 1 }; x",
+      "identifier not found: x",
+    },
+    {
+      "fn() { let g = fn() { x }; g(); let x = 5; }()",
+      "identifier not found: x",
+    },
+    {
+      "fn() { if (false) { let x = 1 }; x }()",
+      "identifier not found: x",
+    },
+    {
+      `"Hello" - "World"`,
+      "unknown operator: STRING - STRING",
+    },
+    {
+      `"Hello" + 1`,
+      "type mismatch: STRING + INTEGER",
+    },
+    {
+      "1[0]",
+      "index operator not supported: INTEGER",
+    },
+    {
+      `{"name": "Monk"}[fn(x) { x }];`,
+      "unusable as hash key: FUNCTION",
+    },
+    {
+      `{fn(x) { x }: 1};`,
+      "unusable as hash key: FUNCTION",
+    },
+    {
+      `{[1]: 1};`,
+      "unusable as hash key: ARRAY",
+    },
+    {
+      "5(1)",
+      "not a function: INTEGER",
+    },
+    {
+      "fn(a, b) { a }(1)",
+      "wrong number of arguments to anonymous function: want=2, got=1",
+    },
+    {
+      "let f = fn(a) { a }; f(1, 2)",
+      "wrong number of arguments to `f`: want=1, got=2",
+    },
+    {
+      "1 / 0",
+      "division by zero",
+    },
+    {
+      "1 % 0",
+      "modulo by zero",
+    },
+    {
+      "2 ** -1",
+      "negative exponent: 2 ** -1",
+    },
+    {
+      "1 << -1",
+      "negative shift count: 1 << -1",
+    },
+    {
+      "1 >> -1",
+      "negative shift count: 1 >> -1",
+    },
+    {
+      "~true",
+      "unknown operator: ~BOOLEAN",
+    },
+    {
+      "true & false",
+      "unknown operator: BOOLEAN & BOOLEAN",
+    },
+    {
+      `"a" <= "b"`,
+      "unknown operator: STRING <= STRING",
+    },
   }
 
   for _, tt := range tests {
@@ -211,93 +422,1114 @@ if (10 > 1) {
   }
 }
 
-func TestFunctionObject(t *testing.T) {
-  input := "fn(x) { x + 2; };"
+func TestWrappingArithmetic(t *testing.T) {
+  engine, err := NewEngine(testEngine, Options{
+    Arithmetic: ARITHMETIC_WRAPPING,
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
 
-  evaluated := testEval(input)
-  fn, ok := evaluated.(*Function)
+  tests := []struct {
+    input    string
+    expected int64
+  }{
+    {"9223372036854775807 + 1", math.MinInt64},
+    {"-9223372036854775807 - 2", math.MaxInt64},
+    {"4611686018427387904 * 2", math.MinInt64},
+    {"(-9223372036854775807 - 1) / -1", math.MinInt64},
+    {"-(-9223372036854775807 - 1)", math.MinInt64},
+    {"2 ** 64 + 3", 3},
+    {"3 ** 41", -420491770248316829},
+    {"(-9223372036854775807 - 1) % -1", 0},
+    {"1 << 63", math.MinInt64},
+    {"3 << 64", 0},
+    {"99999999999999999999", 7766279631452241919},
+    {"99999999999999999999 * 2", -2914184810805067778},
+    {"0xffff_ffff_ffff_ffff", -1},
+    {"-0xffff_ffff_ffff_ffff", 1},
+    {`int("99999999999999999999") + 0`, 7766279631452241919},
+  }
+
+  for _, tt := range tests {
+    program := NewParser(NewLexer(tt.input)).Parse()
+    testIntegerObject(t, engine.Run(program), tt.expected)
+  }
+
+  evaluated := engine.Run(NewParser(NewLexer("1 / 0")).Parse())
+
+  errObj, ok := evaluated.(*Error)
   if !ok {
-    t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+    t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+  }
+
+  if errObj.Message != "division by zero" {
+    t.Errorf("wrong error message. got=%q", errObj.Message)
   }
+}
 
-  if len(fn.Parameters) != 1 {
-    t.Fatalf("function has wrong parameters. Parameters=%+v",
-      fn.Parameters)
+func TestCheckedArithmetic(t *testing.T) {
+  engine, err := NewEngine(testEngine, Options{
+    Arithmetic: ARITHMETIC_CHECKED,
+  })
+  if err != nil {
+    t.Fatal(err)
   }
 
-  if fn.Parameters[0].String() != "x" {
-    t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {
+      "2 ** 63",
+      "integer overflow: 2 ** 63",
+    },
+    {
+      "1 << 63",
+      "integer overflow: 1 << 63",
+    },
+    {
+      "9223372036854775807 + 1",
+      "integer overflow: 9223372036854775807 + 1",
+    },
+    {
+      "-9223372036854775807 - 2",
+      "integer overflow: -9223372036854775807 - 2",
+    },
+    {
+      "4611686018427387904 * 2",
+      "integer overflow: 4611686018427387904 * 2",
+    },
+    {
+      "(-9223372036854775807 - 1) / -1",
+      "integer overflow: -9223372036854775808 / -1",
+    },
+    {
+      "-(-9223372036854775807 - 1)",
+      "integer overflow: -(-9223372036854775808)",
+    },
+    {
+      "99999999999999999999",
+      "integer overflow: 99999999999999999999",
+    },
+    {
+      "99999999999999999999 * 2",
+      "integer overflow: 99999999999999999999",
+    },
+    {
+      `int("99999999999999999999") + 1`,
+      "integer overflow: 99999999999999999999",
+    },
   }
 
-  expectedBody := "(x + 2)"
+  for _, tt := range tests {
+    evaluated := engine.Run(NewParser(NewLexer(tt.input)).Parse())
+
+    errObj, ok := evaluated.(*Error)
+    if !ok {
+      t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+      continue
+    }
 
-  if fn.Body.String() != expectedBody {
-    t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+    if errObj.Message != tt.expected {
+      t.Errorf("wrong error message. expected=%q, got=%q",
+        tt.expected, errObj.Message)
+    }
   }
 }
 
-func TestFunctionApplication(t *testing.T) {
+func TestBigIntegers(t *testing.T) {
   tests := []struct {
     input    string
-    expected int64
+    expected string
   }{
-    {"let identity = fn(x) { x; }; identity(5);", 5},
-    {"let identity = fn(x) { return x; }; identity(5);", 5},
-    {"let double = fn(x) { x * 2; }; double(5);", 10},
-    {"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
-    {"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
-    {"fn(x) { x; }(5)", 5},
+    {"9223372036854775807 + 1", "9223372036854775808"},
+    {"-9223372036854775807 - 2", "-9223372036854775809"},
+    {"-(-9223372036854775807 - 1)", "9223372036854775808"},
+    {"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+    {"2 ** 100", "1267650600228229401496703205376"},
+    {"0xffff_ffff_ffff_ffff", "18446744073709551615"},
+    {"1 << 70", "1180591620717411303424"},
+    {"99999999999999999999", "99999999999999999999"},
+    {"-99999999999999999999 * 10", "-999999999999999999990"},
+    {
+      "let f = fn(n) { if (n < 2) { 1 } else { n * f(n - 1) } }; f(25)",
+      "15511210043330985984000000",
+    },
+    {"~(1 << 64)", "-18446744073709551617"},
+    {"(1 << 64) % 7 + (1 << 64) / (1 << 62)", "6"},
+    {"(1 << 64) >> 60 & 31 | 2 ^ 1", "19"},
+    {"2 ** 64 * 1.0", "1.8446744073709552e+19"},
+    {"(2 ** 64 - 1) - 2 ** 64 + 1", "0"},
+    {"2 ** 64 == 18446744073709551616", "true"},
+    {"2 ** 64 > 2 ** 63 && 1 < 2 ** 64", "true"},
+    {"{2 ** 64: 1}[18446744073709551616]", "1"},
+    {"(2 ** 64) / 0", "division by zero"},
+    {"(2 ** 64) ** -1", "negative exponent: 18446744073709551616 ** -1"},
+    {`2 ** 64 + "a"`, "type mismatch: BIGINT + STRING"},
+    {"2 ** (2 ** 40)", "integer too large: more than 4194304 bits"},
+    {"1 << (2 ** 40)", "integer too large: more than 4194304 bits"},
+    {"(2 ** 64) ** (2 ** 64)", "integer too large: more than 4194304 bits"},
+    {"2 ** 4194304", "integer too large: more than 4194304 bits"},
+    {"(1 << 4194303) * 2", "integer too large: more than 4194304 bits"},
+    {"2 ** 4194303 > 0", "true"},
+    {"(1 << 4194303) > 0", "true"},
+    {"1 ** (2 ** 70)", "1"},
+    {"(-1) ** (2 ** 70 + 1)", "-1"},
+    {"0 << (2 ** 70)", "0"},
+    {"int(2 ** 70)", "1180591620717411303424"},
+    {`int("99999999999999999999")`, "99999999999999999999"},
+    {`int("-99999999999999999999") + 1`, "-99999999999999999998"},
+    {"int(1e20)", "100000000000000000000"},
   }
 
   for _, tt := range tests {
-    testIntegerObject(t, testEval(tt.input), tt.expected)
+    evaluated := testEval(tt.input)
+
+    if errObj, ok := evaluated.(*Error); ok {
+      evaluated = &String{Value: errObj.Message}
+    }
+
+    if evaluated.Inspect() != tt.expected {
+      t.Errorf("%q: wrong result. expected=%s, got=%s (%T)",
+        tt.input, tt.expected, evaluated.Inspect(), evaluated)
+    }
   }
+
+  // Results that fit in an Integer are demoted to one.
+  testIntegerObject(t, testEval("(2 ** 64) / (2 ** 62)"), 4)
 }
 
-func TestClosures(t *testing.T) {
-  input := `
-let newAdder = fn(x) {
-  fn(y) { x + y };
-};
+func TestErrorPositions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected Position
+  }{
+    {"5 + true;", Position{Line: 1, Column: 3, Offset: 2}},
+    {"let a = 1;\n  -true", Position{Line: 2, Column: 3, Offset: 13}},
+    {"let a = 1;\nfoobar;", Position{Line: 2, Column: 1, Offset: 11}},
+    {
+      "let f = fn(x) {\n  x + true\n};\nf(1)",
+      Position{Line: 2, Column: 5, Offset: 20},
+    },
+    {`len(1, 2)`, Position{Line: 1, Column: 4, Offset: 3}},
+    {"1[0]", Position{Line: 1, Column: 2, Offset: 1}},
+  }
 
-let addTwo = newAdder(2);
-addTwo(2);
-`
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
 
-  testIntegerObject(t, testEval(input), 4)
+    errObj, ok := evaluated.(*Error)
+    if !ok {
+      t.Errorf("no error object returned. got=%T(%+v)",
+        evaluated, evaluated)
+      continue
+    }
+
+    if errObj.Position != tt.expected {
+      t.Errorf("%q: wrong error position. expected=%+v, got=%+v",
+        tt.input, tt.expected, errObj.Position)
+    }
+  }
 }
 
-func testEval(input string) Object {
-  env := NewEnvironment()
-  return Eval(NewParser(NewLexer(input)).Parse(), env)
+func TestStackTraces(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected []StackFrame
+  }{
+    {"5 + true;", nil},
+    {
+      "let f = fn(x) {\n  x + true\n};\nf(1)",
+      []StackFrame{{"f", Position{Line: 4, Column: 2, Offset: 31}}},
+    },
+    {
+      "let inner = fn() { 1 + true };\n" +
+        "let outer = fn() { inner() };\n" +
+        "outer()",
+      []StackFrame{
+        {"inner", Position{Line: 2, Column: 25, Offset: 55}},
+        {"outer", Position{Line: 3, Column: 6, Offset: 66}},
+      },
+    },
+    {
+      "let f = fn() { len(1) };\nf()",
+      []StackFrame{
+        {"len", Position{Line: 1, Column: 19, Offset: 18}},
+        {"f", Position{Line: 2, Column: 2, Offset: 26}},
+      },
+    },
+    {
+      "let f = fn() { len(1, 2) };\nf()",
+      []StackFrame{{"f", Position{Line: 2, Column: 2, Offset: 29}}},
+    },
+    {
+      "fn() { -true }()",
+      []StackFrame{{"", Position{Line: 1, Column: 15, Offset: 14}}},
+    },
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    errObj, ok := evaluated.(*Error)
+    if !ok {
+      t.Errorf("no error object returned. got=%T(%+v)",
+        evaluated, evaluated)
+      continue
+    }
+
+    if len(errObj.Trace) != len(tt.expected) {
+      t.Errorf("%q: wrong trace length. expected=%+v, got=%+v",
+        tt.input, tt.expected, errObj.Trace)
+      continue
+    }
+
+    for i, frame := range tt.expected {
+      if errObj.Trace[i] != frame {
+        t.Errorf("%q: wrong frame %d. expected=%+v, got=%+v",
+          tt.input, i, frame, errObj.Trace[i])
+      }
+    }
+  }
 }
 
-func testIntegerObject(t *testing.T, obj Object, expected int64) bool {
-  result, ok := obj.(*Integer)
+func TestRecursionDepth(t *testing.T) {
+  engine, err := NewEngine(testEngine, Options{MaxDepth: 50})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  run := func(input string) Object {
+    return engine.Run(NewParser(NewLexer(input)).Parse())
+  }
+
+  run("let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } };")
+
+  testIntegerObject(t, run("count(49)"), 49)
+
+  evaluated := run("count(50)")
+
+  errObj, ok := evaluated.(*Error)
   if !ok {
-    t.Errorf("Object is not an Integer, got=%T (%+v)", obj, obj)
-    return false
+    t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
   }
-  if result.Value != expected {
-    t.Errorf(
-      "Object has wrong value, got=%d, want=%d",
-      result.Value,
-      expected,
-    )
-    return false
+
+  expected := "maximum recursion depth exceeded in `count`"
+
+  if errObj.Message != expected {
+    t.Errorf("wrong error message. expected=%q, got=%q",
+      expected, errObj.Message)
   }
-  return true
-}
 
-func testBooleanObject(t *testing.T, obj Object, expected bool) bool {
-  result, ok := obj.(*Boolean)
+  if len(errObj.Trace) != 50 {
+    t.Errorf("wrong trace length. expected=50, got=%d", len(errObj.Trace))
+  }
+
+  evaluated = run("fn(f) { f(f) }(fn(f) { f(f) })")
+
+  errObj, ok = evaluated.(*Error)
   if !ok {
-    t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
-    return false
+    t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
   }
-  if result.Value != expected {
-    t.Errorf(
-      "object has wrong value. got=%t, want=%t",
+
+  expected = "maximum recursion depth exceeded in anonymous function"
+
+  if errObj.Message != expected {
+    t.Errorf("wrong error message. expected=%q, got=%q",
+      expected, errObj.Message)
+  }
+
+  testIntegerObject(t, testEval("let count = fn(n) { if (n == 0) { 0 } "+
+    "else { 1 + count(n - 1) } }; count(5000)"), 5000)
+}
+
+func TestMaxDepthRange(t *testing.T) {
+  tests := []struct {
+    depth    int
+    expected string
+  }{
+    {0, ""},
+    {1, ""},
+    {MAX_DEPTH_LIMIT, ""},
+    {-1, "max depth must be between 1 and 100000, got -1"},
+    {MAX_DEPTH_LIMIT + 1, "max depth must be between 1 and 100000, got 100001"},
+  }
+
+  for _, tt := range tests {
+    _, err := NewEngine(testEngine, Options{MaxDepth: tt.depth})
+
+    if tt.expected == "" {
+      if err != nil {
+        t.Errorf("unexpected error for depth %d: %s", tt.depth, err)
+      }
+
+      continue
+    }
+
+    if err == nil || err.Error() != tt.expected {
+      t.Errorf("wrong error for depth %d. expected=%q, got=%v",
+        tt.depth, tt.expected, err)
+    }
+  }
+}
+
+func TestFunctionObject(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {"fn(x) { x + 2; };", "fn(x) {\n(x + 2)\n}"},
+    {
+      "fn(x, y = 10, ...rest) { x + y };",
+      "fn(x, y = 10, ...rest) {\n(x + y)\n}",
+    },
+    {"let f = fn() { 1 }; f", "fn() {\n1\n}"},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    if evaluated.Type() != FUNCTION_OBJ {
+      t.Errorf("object is not a function. got=%T (%+v)",
+        evaluated, evaluated)
+      continue
+    }
+
+    if evaluated.Inspect() != tt.expected {
+      t.Errorf("function inspects wrong. want=%q, got=%q",
+        tt.expected, evaluated.Inspect())
+    }
+  }
+}
+
+func TestFunctionApplication(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected int64
+  }{
+    {"let identity = fn(x) { x; }; identity(5);", 5},
+    {"let identity = fn(x) { return x; }; identity(5);", 5},
+    {"let double = fn(x) { x * 2; }; double(5);", 10},
+    {"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+    {"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+    {"fn(x) { x; }(5)", 5},
+    {"let f = fn() { g() }; let g = fn() { 1 }; f()", 1},
+    {
+      `
+let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+if (even(10)) { 1 } else { 0 }
+`,
+      1,
+    },
+    {
+      `
+let f = fn() {
+  let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+  let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+  if (even(10)) { 1 } else { 0 }
+};
+f()
+`,
+      1,
+    },
+    {"fn() { let g = fn() { x }; let x = 5; g() }()", 5},
+    {"fn() { let g = fn() { fn() { x } }; let x = 5; g()() }()", 5},
+    {"let x = 1; fn() { let y = x; let x = 2; x * 10 + y }()", 21},
+  }
+
+  for _, tt := range tests {
+    testIntegerObject(t, testEval(tt.input), tt.expected)
+  }
+}
+
+func TestDefaultParameters(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"let f = fn(x, y = 10) { x + y }; f(1)", 11},
+    {"let f = fn(x, y = 10) { x + y }; f(1, 2)", 3},
+    {"let f = fn(x = 1, y = 2) { x * 10 + y }; f()", 12},
+    {"let f = fn(x = 1, y = 2) { x * 10 + y }; f(3)", 32},
+    {"let n = 1; let f = fn(x = n) { x }; let n = 2; f()", 2},
+    {"let x = 5; let f = fn(x, y = x) { y }; f(1)", 5},
+    {"let make = fn(n) { fn(x = n) { x } }; make(7)()", 7},
+    {"let calls = fn(x = [1]) { push(x, 2) }; calls(); calls()", []int{1, 2}},
+    {"let f = fn(x = 1 + true) { x }; f(2)", 2},
+    {"let f = fn(x = 1 + true) { x }; f()",
+      "type mismatch: INTEGER + BOOLEAN"},
+    {"let f = fn(x, y = 1) { x }; f()",
+      "wrong number of arguments to `f`: want=1 or 2, got=0"},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case []int:
+      array, ok := evaluated.(*Array)
+      if !ok {
+        t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if len(array.Elements) != len(expected) {
+        t.Errorf("wrong num of elements. want=%d, got=%d",
+          len(expected), len(array.Elements))
+        continue
+      }
+
+      for i, element := range expected {
+        testIntegerObject(t, array.Elements[i], int64(element))
+      }
+    case string:
+      errObj, ok := evaluated.(*Error)
+      if !ok {
+        t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if errObj.Message != expected {
+        t.Errorf("wrong error message. expected=%q, got=%q",
+          expected, errObj.Message)
+      }
+    }
+  }
+}
+
+func TestRestParameters(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected []int
+  }{
+    {"let f = fn(...xs) { xs }; f()", []int{}},
+    {"let f = fn(...xs) { xs }; f(1, 2, 3)", []int{1, 2, 3}},
+    {"let f = fn(x, ...xs) { push(xs, x) }; f(1, 2, 3)", []int{2, 3, 1}},
+    {"let f = fn(x, y = 2, ...xs) { [x, y, len(xs)] }; f(1)", []int{1, 2, 0}},
+    {
+      "let f = fn(x, y = 2, ...xs) { [x, y, len(xs)] }; f(1, 5, 6, 7)",
+      []int{1, 5, 2},
+    },
+    {"let f = fn(...xs) { xs }; f(...[1, 2])", []int{1, 2}},
+    {"let f = fn(...xs) { xs }; f(1, ...[], ...[2, 3], 4)", []int{1, 2, 3, 4}},
+    {
+      "let log = fn(f) { fn(...args) { f(...args) } };" +
+        "log(fn(a, b) { [b, a] })(1, 2)",
+      []int{2, 1},
+    },
+    {"let args = [[1], 2]; push(...args)", []int{1, 2}},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    array, ok := evaluated.(*Array)
+    if !ok {
+      t.Errorf("%q: obj not Array. got=%T (%+v)",
+        tt.input, evaluated, evaluated)
+      continue
+    }
+
+    if len(array.Elements) != len(tt.expected) {
+      t.Errorf("%q: wrong num of elements. want=%d, got=%d",
+        tt.input, len(tt.expected), len(array.Elements))
+      continue
+    }
+
+    for i, element := range tt.expected {
+      testIntegerObject(t, array.Elements[i], int64(element))
+    }
+  }
+
+  errorTests := []struct {
+    input    string
+    expected string
+  }{
+    {"let f = fn(x, ...xs) { xs }; f()",
+      "wrong number of arguments to `f`: want=at least 1, got=0"},
+    {"let f = fn(x, y) { x }; f(...[1, 2, 3])",
+      "wrong number of arguments to `f`: want=2, got=3"},
+    {"len(...1)", "spread argument must be ARRAY, got INTEGER"},
+  }
+
+  for _, tt := range errorTests {
+    evaluated := testEval(tt.input)
+
+    errObj, ok := evaluated.(*Error)
+    if !ok {
+      t.Errorf("no error object returned. got=%T(%+v)",
+        evaluated, evaluated)
+      continue
+    }
+
+    if errObj.Message != tt.expected {
+      t.Errorf("wrong error message. expected=%q, got=%q",
+        tt.expected, errObj.Message)
+    }
+  }
+}
+
+func TestAssignment(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"let x = 1; x = 2; x", 2},
+    {"let x = 1; x = 2", 2},
+    {"let x = 1; let y = 1; x = y = 3; x + y", 6},
+    {"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+    {`let s = "a"; s += "b"; s`, "ab"},
+    {"let x = 1; let f = fn() { x = 2 }; f(); x", 2},
+    {"let x = 1; let f = fn() { let x = 5; x = 2 }; f(); x", 1},
+    {"let i = 0; while (i < 3) { i += 1 }; i", 3},
+    {
+      "let counter = fn() { let n = 0; fn() { n += 1 } };" +
+        "let c = counter(); c(); c(); c()",
+      3,
+    },
+    {
+      "let counter = fn() { let n = 0; fn() { n += 1 } };" +
+        "let a = counter(); let b = counter(); a(); a(); b()",
+      1,
+    },
+    {
+      "let f = fn() { let n = 0; let inc = fn() { n += 1 };" +
+        "inc(); inc(); n }; f()",
+      2,
+    },
+    {
+      "let f = fn() { let n = 1; let g = fn() { fn() { n *= 10 } };" +
+        "g()(); g()(); n }; f()",
+      100,
+    },
+    {
+      "let f = fn() { let n = 0; fn() { n } }; let g = f(); g()",
+      0,
+    },
+    {"let f = fn() { f = 1 }; f(); f", 1},
+    {"let f = fn() { f = 1; f }; f()", 1},
+    {"let f = fn() { f }; let g = f; f = 2; g()", 2},
+    {"let w = fn() { let f = fn() { f = 1; f }; f() }; w()", 1},
+    {"const f = fn() { f = 1 }; f()", "assignment to constant: f"},
+    {"x = 1", "assignment to undeclared identifier: x"},
+    {"x += 1", "assignment to undeclared identifier: x"},
+    {"fn() { y = 1 }()", "assignment to undeclared identifier: y"},
+    {"let g = fn() { y = 1 }; let y = 0; g(); y", 1},
+    {"let f = fn() { if (false) { zz = 1 }; 2 }; f()", 2},
+    {"fn() { let g = fn() { x += 2 }; let x = 1; g(); x }()", 3},
+    {"let g = fn() { y = 1 }; g()", "assignment to undeclared identifier: y"},
+    {
+      "if (false) { let y = 0 }; y = 1",
+      "assignment to undeclared identifier: y",
+    },
+    {"let x = true; x += 1", "type mismatch: BOOLEAN + INTEGER"},
+    {"let x = 1; x /= 0", "division by zero"},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case string:
+      if errObj, ok := evaluated.(*Error); ok {
+        if errObj.Message != expected {
+          t.Errorf("wrong error message. expected=%q, got=%q",
+            expected, errObj.Message)
+        }
+        continue
+      }
+      testStringObject(t, evaluated, expected)
+    }
+  }
+}
+
+func TestConstants(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"const x = 1; x", 1},
+    {"const x = 1; let f = fn() { let x = 2; x }; f() + x", 3},
+    {"const f = fn(n) { n * 2 }; f(21)", 42},
+    {"let x = 1; const x = 2; x", 2},
+    {"const x = 1; x = 2", "assignment to constant: x"},
+    {"const x = 1; x += 2", "assignment to constant: x"},
+    {"const x = 1; let x = 2", "redeclaration of constant: x"},
+    {"const x = 1; const x = 2", "redeclaration of constant: x"},
+    {"const x = 1; fn() { x = 2 }()", "assignment to constant: x"},
+    {"const f = fn() { f = 1 }; f()", "assignment to constant: f"},
+    {
+      "let f = fn() { const n = 1; fn() { n = 2 } }; f()()",
+      "assignment to constant: n",
+    },
+    {"const x = 1; for (x in [1]) { x }", "redeclaration of constant: x"},
+    {"let i = 0; while (i < 2) { const step = 1; i += step; }; i", 2},
+    {"let s = 0; for (x in [1, 2]) { const y = x * 10; s += y }; s", 30},
+    {
+      "let i = 0; while (i < 2) { const n = 1; const n = 2; i += 1 }",
+      "redeclaration of constant: n",
+    },
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case string:
+      errObj, ok := evaluated.(*Error)
+      if !ok {
+        t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if errObj.Message != expected {
+        t.Errorf("wrong error message. expected=%q, got=%q",
+          expected, errObj.Message)
+      }
+    }
+  }
+}
+
+func TestLogicalOperators(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"true && true", true},
+    {"true && false", false},
+    {"false && true", false},
+    {"false || true", true},
+    {"false || false", false},
+    {"1 && \"a\"", true},
+    {"if (false) { 1 } || 0", true},
+    {"1 < 2 && 2 < 3", true},
+    {"false && len(1)", false},
+    {"true || 1 / 0", true},
+    {"let n = 0; let f = fn() { n += 1; true }; false && f(); n", 0},
+    {"let n = 0; let f = fn() { n += 1; true }; true && f(); n", 1},
+    {"true && len(1)", "argument to `len` not supported, got INTEGER"},
+    {"false || 1 / 0", "division by zero"},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case bool:
+      testBooleanObject(t, evaluated, expected)
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case string:
+      errObj, ok := evaluated.(*Error)
+      if !ok {
+        t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if errObj.Message != expected {
+        t.Errorf("wrong error message. expected=%q, got=%q",
+          expected, errObj.Message)
+      }
+    }
+  }
+}
+
+func TestLoops(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"let i = 0; while (i < 5) { let i = i + 1; }; i", 5},
+    {"while (false) { 1 }", nil},
+    {"let i = 0; while (true) { let i = i + 1; if (i == 3) { break } }; i", 3},
+    {
+      "let n = 0; let i = 0;" +
+        "while (i < 5) { let i = i + 1; if (i == 2) { continue }; " +
+        "let n = n + i }; n",
+      13,
+    },
+    {"let sum = 0; for (x in [1, 2, 3]) { let sum = sum + x }; sum", 6},
+    {"for (x in []) { x }", nil},
+    {"let s = \"\"; for (c in \"abc\") { let s = c + s }; s", "cba"},
+    {
+      "let s = \"\"; for (k in {\"b\": 1, \"a\": 2}) { let s = s + k }; s",
+      "ab",
+    },
+    {
+      "let n = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue }; " +
+        "if (x == 4) { break }; let n = n + x }; n",
+      4,
+    },
+    {
+      "let n = 0; for (x in [1, 2]) { for (y in [10, 20]) { " +
+        "if (y == 20) { break }; let n = n + x * y } }; n",
+      30,
+    },
+    {
+      "let find = fn(xs) { for (x in xs) { if (x > 1) { return x } } };" +
+        "find([1, 5, 7])",
+      5,
+    },
+    {"let f = fn() { while (false) {} }; f()", nil},
+    {"for (x in 5) { x }", "cannot iterate over INTEGER"},
+    {
+      "let n = 0; while (n < 5) { n += 1; " +
+        "let x = if (n == 2) { break; } else { n }; } n",
+      2,
+    },
+    {
+      "let n = 0; let m = 0; while (n < 5) { n += 1; " +
+        "m = if (n % 2 == 0) { continue } else { m + n }; } m",
+      9,
+    },
+    {
+      "let n = 0; for (x in [1, 2, 3]) { " +
+        "n = n + len([x, if (x == 2) { break } else { x }]) }; n",
+      2,
+    },
+    {
+      "let n = 0; for (x in [1, 2, 3]) { " +
+        "n += int(if (x == 2) { continue } else { x }) }; n",
+      4,
+    },
+    {
+      "let f = fn() { let x = if (true) { return 1 } else { 2 }; 3 }; f()",
+      1,
+    },
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case nil:
+      testNullObject(t, evaluated)
+    case string:
+      if errObj, ok := evaluated.(*Error); ok {
+        if errObj.Message != expected {
+          t.Errorf("wrong error message. expected=%q, got=%q",
+            expected, errObj.Message)
+        }
+        continue
+      }
+      testStringObject(t, evaluated, expected)
+    }
+  }
+}
+
+func TestClosures(t *testing.T) {
+  input := `
+let newAdder = fn(x) {
+  fn(y) { x + y };
+};
+
+let addTwo = newAdder(2);
+addTwo(2);
+`
+
+  testIntegerObject(t, testEval(input), 4)
+}
+
+func TestArrayLiterals(t *testing.T) {
+  evaluated := testEval("[1, 2 * 2, 3 + 3]")
+
+  result, ok := evaluated.(*Array)
+  if !ok {
+    t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+  }
+
+  if len(result.Elements) != 3 {
+    t.Fatalf("array has wrong num of elements. got=%d",
+      len(result.Elements))
+  }
+
+  testIntegerObject(t, result.Elements[0], 1)
+  testIntegerObject(t, result.Elements[1], 4)
+  testIntegerObject(t, result.Elements[2], 6)
+
+  if result.Inspect() != "[1, 4, 6]" {
+    t.Errorf("array.Inspect() wrong. got=%q", result.Inspect())
+  }
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {"[1, 2, 3][0]", 1},
+    {"[1, 2, 3][1]", 2},
+    {"[1, 2, 3][2]", 3},
+    {"let i = 0; [1][i];", 1},
+    {"[1, 2, 3][1 + 1];", 3},
+    {"let myArray = [1, 2, 3]; myArray[2];", 3},
+    {
+      "let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
+      6,
+    },
+    {"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+    {"[[1, 2], [3, 4]][1][0]", 3},
+    {"[1, 2, 3][3]", nil},
+    {"[1, 2, 3][-1]", nil},
+    {"[][0]", nil},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+    integer, ok := tt.expected.(int)
+    if ok {
+      testIntegerObject(t, evaluated, int64(integer))
+    } else {
+      testNullObject(t, evaluated)
+    }
+  }
+}
+
+func TestHashLiterals(t *testing.T) {
+  input := `let two = "two";
+  {
+    "one": 10 - 9,
+    two: 1 + 1,
+    "thr" + "ee": 6 / 2,
+    4: 4,
+    true: 5,
+    false: 6
+  }`
+
+  evaluated := testEval(input)
+
+  result, ok := evaluated.(*Hash)
+  if !ok {
+    t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+  }
+
+  expected := map[HashKey]int64{
+    (&String{Value: "one"}).HashKey():   1,
+    (&String{Value: "two"}).HashKey():   2,
+    (&String{Value: "three"}).HashKey(): 3,
+    (&Integer{Value: 4}).HashKey():      4,
+    TRUE_LIT.HashKey():                  5,
+    FALSE_LIT.HashKey():                 6,
+  }
+
+  if len(result.Pairs) != len(expected) {
+    t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+  }
+
+  for expectedKey, expectedValue := range expected {
+    pair, ok := result.Pairs[expectedKey]
+    if !ok {
+      t.Errorf("no pair for given key in Pairs")
+    }
+
+    testIntegerObject(t, pair.Value, expectedValue)
+  }
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {`{"foo": 5}["foo"]`, 5},
+    {`{"foo": 5}["bar"]`, nil},
+    {`let key = "foo"; {"foo": 5}[key]`, 5},
+    {`{}["foo"]`, nil},
+    {`{5: 5}[5]`, 5},
+    {`{true: 5}[true]`, 5},
+    {`{false: 5}[false]`, 5},
+    {`{"a": {"b": 7}}["a"]["b"]`, 7},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+    integer, ok := tt.expected.(int)
+    if ok {
+      testIntegerObject(t, evaluated, int64(integer))
+    } else {
+      testNullObject(t, evaluated)
+    }
+  }
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected interface{}
+  }{
+    {`len("")`, 0},
+    {`len("four")`, 4},
+    {`len("hello world")`, 11},
+    {`len("héllo")`, 5},
+    {`let n = 0; for (c in "héllo") { n += 1 }; n`, 5},
+    {`len([1, 2, 3])`, 3},
+    {`len({"a": 1, "b": 2})`, 2},
+    {`len(1)`, "argument to `len` not supported, got INTEGER"},
+    {
+      `len("one", "two")`,
+      "wrong number of arguments to `len`: want=1, got=2",
+    },
+    {`first([1, 2, 3])`, 1},
+    {`first([])`, nil},
+    {`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
+    {`last([1, 2, 3])`, 3},
+    {`last([])`, nil},
+    {`last(1)`, "argument to `last` must be ARRAY, got INTEGER"},
+    {`rest([1, 2, 3])`, []int{2, 3}},
+    {`rest([])`, nil},
+    {`push([], 1)`, []int{1}},
+    {`let a = [1]; push(a, 2); a`, []int{1}},
+    {`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+    {`puts("hello", "world!")`, nil},
+    {`type(1)`, "INTEGER"},
+    {`type("one")`, "STRING"},
+    {`type([])`, "ARRAY"},
+    {`type(len)`, "BUILTIN"},
+    {`str(12)`, "12"},
+    {`str("12")`, "12"},
+    {`str([1, true])`, "[1, true]"},
+    {`int("42")`, 42},
+    {`int(" -7 ")`, -7},
+    {`int(7)`, 7},
+    {`int(true)`, 1},
+    {`int(false)`, 0},
+    {`int(2.5)`, 2},
+    {`int(-2.9)`, -2},
+    {`int(1e3)`, 1000},
+    {`int("+5")`, 5},
+    {`int((-1) ** 0.5)`, "cannot convert NaN to integer"},
+    {`int(10.0 ** 400)`, "cannot convert +Inf to integer"},
+    {`int("1.5")`, `could not parse "1.5" as integer`},
+    {`int("seven")`, `could not parse "seven" as integer`},
+    {`int([])`, "argument to `int` not supported, got ARRAY"},
+    {`exit("1")`, "argument to `exit` must be INTEGER, got STRING"},
+    {
+      `exit(1, 2)`,
+      "wrong number of arguments to `exit`: want=0 or 1, got=2",
+    },
+    {`let len = fn(x) { 42 }; len("a")`, 42},
+  }
+
+  for _, tt := range tests {
+    evaluated := testEval(tt.input)
+
+    switch expected := tt.expected.(type) {
+    case int:
+      testIntegerObject(t, evaluated, int64(expected))
+    case nil:
+      testNullObject(t, evaluated)
+    case []int:
+      array, ok := evaluated.(*Array)
+      if !ok {
+        t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+        continue
+      }
+
+      if len(array.Elements) != len(expected) {
+        t.Errorf("wrong num of elements. want=%d, got=%d",
+          len(expected), len(array.Elements))
+        continue
+      }
+
+      for i, expectedElem := range expected {
+        testIntegerObject(t, array.Elements[i], int64(expectedElem))
+      }
+    case string:
+      switch evaluated := evaluated.(type) {
+      case *Error:
+        if evaluated.Message != expected {
+          t.Errorf("wrong error message. expected=%q, got=%q",
+            expected, evaluated.Message)
+        }
+      default:
+        testStringObject(t, evaluated, expected)
+      }
+    }
+  }
+}
+
+// testEngine selects the engine used by testEval, allowing the virtual
+// machine tests to run the evaluator's test cases unchanged.
+var testEngine = ENGINE_EVAL
+
+func testEval(input string) Object {
+  engine, err := NewEngine(testEngine, Options{})
+  if err != nil {
+    panic(err)
+  }
+  return engine.Run(NewParser(NewLexer(input)).Parse())
+}
+
+func testIntegerObject(t *testing.T, obj Object, expected int64) bool {
+  result, ok := obj.(*Integer)
+  if !ok {
+    t.Errorf("Object is not an Integer, got=%T (%+v)", obj, obj)
+    return false
+  }
+  if result.Value != expected {
+    t.Errorf(
+      "Object has wrong value, got=%d, want=%d",
+      result.Value,
+      expected,
+    )
+    return false
+  }
+  return true
+}
+
+func testFloatObject(t *testing.T, obj Object, expected float64) bool {
+  result, ok := obj.(*Float)
+  if !ok {
+    t.Errorf("Object is not a Float, got=%T (%+v)", obj, obj)
+    return false
+  }
+  if result.Value != expected {
+    t.Errorf(
+      "Object has wrong value, got=%g, want=%g",
+      result.Value,
+      expected,
+    )
+    return false
+  }
+  return true
+}
+
+func testBooleanObject(t *testing.T, obj Object, expected bool) bool {
+  result, ok := obj.(*Boolean)
+  if !ok {
+    t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+    return false
+  }
+  if result.Value != expected {
+    t.Errorf(
+      "object has wrong value. got=%t, want=%t",
+      result.Value,
+      expected,
+    )
+    return false
+  }
+  return true
+}
+
+func testStringObject(t *testing.T, obj Object, expected string) bool {
+  result, ok := obj.(*String)
+  if !ok {
+    t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+    return false
+  }
+  if result.Value != expected {
+    t.Errorf(
+      "object has wrong value. got=%q, want=%q",
       result.Value,
       expected,
     )
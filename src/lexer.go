@@ -1,64 +1,206 @@
 package main
 
+import (
+  "fmt"
+  "strconv"
+  "strings"
+  "unicode/utf8"
+)
+
 type Lexer struct {
-  ch           byte
+  ch           rune
+  column       int
+  comments     []Comment
+  diagnostics  []Diagnostic
   input        string
+  line         int
   position     int
   readPosition int
 }
 
 func NewLexer(input string) *Lexer {
-  l := &Lexer{input: input}
+  l := &Lexer{input: input, line: 1}
   l.read()
   return l
 }
 
 func (l *Lexer) Advance() Token {
-  var token Token
+  l.skip()
 
-  l.eat(isWhitespace)
+  position := l.here()
 
-  switch l.ch {
-  case '!':
-    if l.peek() == '=' {
-      ch := l.ch
+  token := l.next()
+  token.Position = position
+  token.End = l.here()
+
+  return token
+}
+
+// Comments returns the comments skipped over in the tokens read so far.
+func (l *Lexer) Comments() []Comment {
+  return l.comments
+}
+
+// Diagnostics returns the errors found in the tokens read so far.
+func (l *Lexer) Diagnostics() []Diagnostic {
+  return l.diagnostics
+}
+
+// error records a diagnostic for the given span of the input.
+func (l *Lexer) error(span Span, format string, a ...interface{}) {
+  l.diagnostics = append(l.diagnostics, errorDiagnostic(span, format, a...))
+}
+
+// unterminated reports a construct that runs to the end of the input,
+// pointing at its opening delimiter, which is width bytes long.
+func (l *Lexer) unterminated(start Position, width int, message string) {
+  end := start
+  end.Column += width
+  end.Offset += width
+
+  l.error(Span{Start: start, End: end}, "%s", message)
+}
+
+// here returns the position of the current character.
+func (l *Lexer) here() Position {
+  return Position{Line: l.line, Column: l.column, Offset: l.position}
+}
+
+// skip consumes the whitespace and comments before the next token.
+func (l *Lexer) skip() {
+  for {
+    l.eat(isWhitespace)
+
+    switch {
+    case l.ch == '/' && l.peek() == '/':
+      l.readLineComment()
+    case l.ch == '/' && l.peek() == '*':
+      l.readBlockComment()
+    default:
+      return
+    }
+  }
+}
+
+// readLineComment consumes a `//` comment, up to but not including the end
+// of the line.
+func (l *Lexer) readLineComment() {
+  start := l.here()
+
+  for l.ch != '\n' && l.ch != 0 {
+    l.read()
+  }
+
+  l.comment(start)
+}
+
+// readBlockComment consumes a `/* */` comment. Block comments nest, so that
+// code which already contains one can be commented out.
+func (l *Lexer) readBlockComment() {
+  start := l.here()
+
+  l.read()
+  l.read()
+
+  for depth := 1; depth > 0; {
+    switch {
+    case l.ch == 0:
+      l.unterminated(start, 2, "unterminated block comment")
+      l.comment(start)
+
+      return
+    case l.ch == '/' && l.peek() == '*':
       l.read()
-      token = Token{Kind: NOT_EQ, Literal: string(ch) + string(l.ch)}
-    } else {
-      token = NewToken(BANG, l.ch)
+      depth++
+    case l.ch == '*' && l.peek() == '/':
+      l.read()
+      depth--
     }
+
+    l.read()
+  }
+
+  l.comment(start)
+}
+
+// comment records the comment running from start to the current character.
+func (l *Lexer) comment(start Position) {
+  l.comments = append(l.comments, Comment{
+    Text: l.input[start.Offset:l.position],
+    Span: Span{Start: start, End: l.here()},
+  })
+}
+
+func (l *Lexer) next() Token {
+  var token Token
+
+  switch l.ch {
+  case '"':
+    token = Token{Kind: STRING, Literal: l.readString()}
+  case '!':
+    token = l.either('=', BANG, NOT_EQ)
+  case '&':
+    token = l.either('&', AMPERSAND, AND)
   case '(':
     token = NewToken(LPAREN, l.ch)
   case ')':
     token = NewToken(RPAREN, l.ch)
+  case '%':
+    token = NewToken(PERCENT, l.ch)
   case '*':
-    token = NewToken(ASTERISK, l.ch)
+    if l.peek() == '*' {
+      token = l.either('*', ASTERISK, POWER)
+    } else {
+      token = l.either('=', ASTERISK, ASTERISK_ASSIGN)
+    }
   case '+':
-    token = NewToken(PLUS, l.ch)
+    token = l.either('=', PLUS, PLUS_ASSIGN)
   case ',':
     token = NewToken(COMMA, l.ch)
   case '-':
-    token = NewToken(MINUS, l.ch)
+    token = l.either('=', MINUS, MINUS_ASSIGN)
+  case '.':
+    if strings.HasPrefix(l.input[l.position:], ELLIPSIS) {
+      l.read()
+      l.read()
+      token = Token{Kind: ELLIPSIS, Literal: ELLIPSIS}
+    } else {
+      token = NewToken(ILLEGAL, l.ch)
+    }
   case '/':
-    token = NewToken(SLASH, l.ch)
+    token = l.either('=', SLASH, SLASH_ASSIGN)
+  case ':':
+    token = NewToken(COLON, l.ch)
   case ';':
     token = NewToken(SEMICOLON, l.ch)
   case '<':
-    token = NewToken(LT, l.ch)
-  case '=':
-    if l.peek() == '=' {
-      ch := l.ch
-      l.read()
-      token = Token{Kind: EQ, Literal: string(ch) + string(l.ch)}
+    if l.peek() == '<' {
+      token = l.either('<', LT, SHIFT_LEFT)
     } else {
-      token = NewToken(ASSIGN, l.ch)
+      token = l.either('=', LT, LT_EQ)
     }
+  case '=':
+    token = l.either('=', ASSIGN, EQ)
   case '>':
-    token = NewToken(GT, l.ch)
+    if l.peek() == '>' {
+      token = l.either('>', GT, SHIFT_RIGHT)
+    } else {
+      token = l.either('=', GT, GT_EQ)
+    }
+  case '[':
+    token = NewToken(LBRACKET, l.ch)
+  case ']':
+    token = NewToken(RBRACKET, l.ch)
+  case '^':
+    token = NewToken(CARET, l.ch)
   case '{':
     token = NewToken(LBRACE, l.ch)
+  case '|':
+    token = l.either('|', PIPE, OR)
   case '}':
     token = NewToken(RBRACE, l.ch)
+  case '~':
+    token = NewToken(TILDE, l.ch)
   case 0:
     token.Literal = ""
     token.Kind = EOF
@@ -68,9 +210,7 @@ func (l *Lexer) Advance() Token {
       token.Kind = LookupIdent(token.Literal)
       return token
     } else if isDigit(l.ch) {
-      token.Kind = INT
-      token.Literal = l.take(isDigit)
-      return token
+      return l.readNumber()
     } else {
       token = NewToken(ILLEGAL, l.ch)
     }
@@ -81,24 +221,251 @@ func (l *Lexer) Advance() Token {
   return token
 }
 
+// either returns a token of the given kind for the current character, or
+// of kind paired when the character is followed by next.
+func (l *Lexer) either(next rune, kind, paired TokenKind) Token {
+  if l.peek() != next {
+    return NewToken(kind, l.ch)
+  }
+
+  ch := l.ch
+  l.read()
+
+  return Token{Kind: paired, Literal: string(ch) + string(l.ch)}
+}
+
+// readNumber consumes a numeric literal: an integer, written in decimal or
+// with a `0x`, `0o` or `0b` prefix, or a decimal float with a fraction, an
+// exponent or both, as in `1.5`, `2e10` and `1.5e-3`. Digits may be
+// separated by underscores. Letters and digits running on from a literal
+// are consumed with it, so that a malformed literal like `0xZZ` is reported
+// as a whole rather than split into several tokens.
+func (l *Lexer) readNumber() Token {
+  start := l.here()
+  kind := TokenKind(INT)
+
+  l.eat(isNumberPart)
+
+  if !hasBasePrefix(l.input[start.Offset:l.position]) {
+    if l.ch == '.' && isDigit(l.peek()) {
+      l.read()
+      l.eat(isNumberPart)
+    }
+
+    last := l.input[l.position-1]
+
+    if (last == 'e' || last == 'E') && (l.ch == '+' || l.ch == '-') &&
+      isDigit(l.peek()) {
+      l.read()
+      l.eat(isNumberPart)
+    }
+
+    if strings.ContainsAny(l.input[start.Offset:l.position], ".eE") {
+      kind = FLOAT
+    }
+  }
+
+  literal := l.input[start.Offset:l.position]
+
+  if offset, message := checkNumber(literal); message != "" {
+    span := Span{Start: start, End: l.here()}
+
+    if offset >= 0 {
+      span.Start.Column += offset
+      span.Start.Offset += offset
+      span.End = span.Start
+    }
+
+    l.error(span, "%s", message)
+  }
+
+  return Token{Kind: kind, Literal: literal}
+}
+
+// checkNumber validates a numeric literal consumed by readNumber. If the
+// literal is malformed, it returns a message describing the problem, and
+// the offset of the offending character, or -1 if the literal as a whole
+// is at fault.
+func checkNumber(literal string) (int, string) {
+  base, name, start := 10, "decimal", 0
+
+  if hasBasePrefix(literal) {
+    start = 2
+
+    switch literal[1] {
+    case 'x', 'X':
+      base, name = 16, "hexadecimal"
+    case 'o', 'O':
+      base, name = 8, "octal"
+    case 'b', 'B':
+      base, name = 2, "binary"
+    }
+  } else if literal[0] == '0' && !strings.ContainsAny(literal, ".eE") {
+    base, name = 8, "octal"
+  }
+
+  digits, exponent := 0, -1
+
+  for i := start; i < len(literal); i++ {
+    ch := rune(literal[i])
+
+    switch {
+    case ch == '_':
+      previous := i == 2 && start == 2 || digitValue(rune(literal[i-1])) < base
+      next := i+1 < len(literal) && digitValue(rune(literal[i+1])) < base
+
+      if !previous || !next {
+        return i, "'_' must separate successive digits"
+      }
+    case base == 10 && ch == '.':
+    case base == 10 && (ch == 'e' || ch == 'E') && exponent == -1:
+      if digits == 0 {
+        return i, fmt.Sprintf("invalid digit %q in %s literal", ch, name)
+      }
+
+      digits, exponent = 0, i
+
+      if i+1 < len(literal) && (literal[i+1] == '+' || literal[i+1] == '-') {
+        i++
+      }
+    case digitValue(ch) < base:
+      digits++
+    default:
+      return i, fmt.Sprintf("invalid digit %q in %s literal", ch, name)
+    }
+  }
+
+  if digits == 0 && exponent != -1 {
+    return exponent, "exponent has no digits"
+  }
+
+  if digits == 0 {
+    return -1, fmt.Sprintf("%s literal has no digits", name)
+  }
+
+  return 0, ""
+}
+
+// hasBasePrefix reports whether a numeric literal starts with `0x`, `0o`
+// or `0b`, in either case.
+func hasBasePrefix(literal string) bool {
+  return len(literal) >= 2 && literal[0] == '0' &&
+    strings.ContainsRune("xXoObB", rune(literal[1]))
+}
+
+// readString consumes a double quoted string literal, decoding escape
+// sequences along the way. A missing closing quote or an invalid escape is
+// reported as a diagnostic, and the rest of the literal is still decoded.
+func (l *Lexer) readString() string {
+  var out strings.Builder
+
+  start := l.here()
+
+  for {
+    l.read()
+
+    switch l.ch {
+    case '"':
+      return out.String()
+    case 0:
+      l.unterminated(start, 1, "unterminated string")
+      return out.String()
+    case '\\':
+      escape := l.here()
+
+      l.read()
+
+      switch l.ch {
+      case 'n':
+        out.WriteByte('\n')
+      case 't':
+        out.WriteByte('\t')
+      case '"':
+        out.WriteByte('"')
+      case '\\':
+        out.WriteByte('\\')
+      case 'u':
+        if r, ok := l.readUnicodeEscape(); ok {
+          out.WriteRune(r)
+          continue
+        }
+
+        l.error(Span{Start: escape, End: l.here()}, "invalid unicode escape")
+
+        switch l.ch {
+        case '"':
+          return out.String()
+        case 0:
+          l.unterminated(start, 1, "unterminated string")
+          return out.String()
+        }
+      case 0:
+        l.unterminated(start, 1, "unterminated string")
+        return out.String()
+      default:
+        end := l.here()
+        end.Column++
+        end.Offset = l.readPosition
+
+        l.error(Span{Start: escape, End: end}, "invalid escape \\%c", l.ch)
+      }
+    default:
+      out.WriteString(l.input[l.position:l.readPosition])
+    }
+  }
+}
+
+// readUnicodeEscape consumes the `{hex digits}` part of a `\u{hex digits}`
+// escape, leaving the lexer on the closing brace.
+func (l *Lexer) readUnicodeEscape() (rune, bool) {
+  if l.peek() != '{' {
+    return utf8.RuneError, false
+  }
+
+  l.read()
+  l.read()
+
+  digits := l.take(isHexDigit)
+
+  if l.ch != '}' || len(digits) == 0 || len(digits) > 6 {
+    return utf8.RuneError, false
+  }
+
+  value, err := strconv.ParseUint(digits, 16, 32)
+
+  if err != nil || !utf8.ValidRune(rune(value)) {
+    return utf8.RuneError, false
+  }
+
+  return rune(value), true
+}
+
 func (l *Lexer) read() {
+  if l.ch == '\n' {
+    l.line += 1
+    l.column = 0
+  }
+
+  l.column += 1
+  l.position = l.readPosition
+
   if l.readPosition >= len(l.input) {
     l.ch = 0
+    l.readPosition += 1
   } else {
-    l.ch = l.input[l.readPosition]
+    ch, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+    l.ch = ch
+    l.readPosition += width
   }
-
-  l.position = l.readPosition
-  l.readPosition += 1
 }
 
-func (l *Lexer) eat(pred func(byte) bool) {
+func (l *Lexer) eat(pred func(rune) bool) {
   for pred(l.ch) {
     l.read()
   }
 }
 
-func (l *Lexer) take(pred func(byte) bool) string {
+func (l *Lexer) take(pred func(rune) bool) string {
   position := l.position
 
   for pred(l.ch) {
@@ -108,10 +475,12 @@ func (l *Lexer) take(pred func(byte) bool) string {
   return l.input[position:l.position]
 }
 
-func (l *Lexer) peek() byte {
+func (l *Lexer) peek() rune {
   if l.readPosition >= len(l.input) {
     return 0
-  } else {
-    return l.input[l.readPosition]
   }
+
+  ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+
+  return ch
 }
@@ -16,7 +16,7 @@ func TestAdvance(t *testing.T) {
 
     let result = add(five, ten);
 
-    !-/*5;
+    !-/ *5;
     5 < 10 > 5;
 
     if (5 < 10) {
@@ -27,6 +27,18 @@ func TestAdvance(t *testing.T) {
 
     10 == 10;
     10 != 9;
+    "foobar"
+    "foo bar"
+    [1, 2];
+    {"foo": "bar"}
+    f(...xs) . ..
+    while for in break continue
+    x += 1 -= 2 *= 3 /= 4
+    const
+    a && b || c & |
+    <= >= % ** **=
+    ^ ~ << >> <<= >>=
+    1.5 2e10 1.5e-3 3E+2 1.x 2e
   `
 
   tests := []struct {
@@ -106,6 +118,71 @@ func TestAdvance(t *testing.T) {
     {NOT_EQ, "!="},
     {INT, "9"},
     {SEMICOLON, ";"},
+    {STRING, "foobar"},
+    {STRING, "foo bar"},
+    {LBRACKET, "["},
+    {INT, "1"},
+    {COMMA, ","},
+    {INT, "2"},
+    {RBRACKET, "]"},
+    {SEMICOLON, ";"},
+    {LBRACE, "{"},
+    {STRING, "foo"},
+    {COLON, ":"},
+    {STRING, "bar"},
+    {RBRACE, "}"},
+    {IDENT, "f"},
+    {LPAREN, "("},
+    {ELLIPSIS, "..."},
+    {IDENT, "xs"},
+    {RPAREN, ")"},
+    {ILLEGAL, "."},
+    {ILLEGAL, "."},
+    {ILLEGAL, "."},
+    {WHILE, "while"},
+    {FOR, "for"},
+    {IN, "in"},
+    {BREAK, "break"},
+    {CONTINUE, "continue"},
+    {IDENT, "x"},
+    {PLUS_ASSIGN, "+="},
+    {INT, "1"},
+    {MINUS_ASSIGN, "-="},
+    {INT, "2"},
+    {ASTERISK_ASSIGN, "*="},
+    {INT, "3"},
+    {SLASH_ASSIGN, "/="},
+    {INT, "4"},
+    {CONST, "const"},
+    {IDENT, "a"},
+    {AND, "&&"},
+    {IDENT, "b"},
+    {OR, "||"},
+    {IDENT, "c"},
+    {AMPERSAND, "&"},
+    {PIPE, "|"},
+    {LT_EQ, "<="},
+    {GT_EQ, ">="},
+    {PERCENT, "%"},
+    {POWER, "**"},
+    {POWER, "**"},
+    {ASSIGN, "="},
+    {CARET, "^"},
+    {TILDE, "~"},
+    {SHIFT_LEFT, "<<"},
+    {SHIFT_RIGHT, ">>"},
+    {SHIFT_LEFT, "<<"},
+    {ASSIGN, "="},
+    {SHIFT_RIGHT, ">>"},
+    {ASSIGN, "="},
+    {FLOAT, "1.5"},
+    {FLOAT, "2e10"},
+    {FLOAT, "1.5e-3"},
+    {FLOAT, "3E+2"},
+    {INT, "1"},
+    {ILLEGAL, "."},
+    {IDENT, "x"},
+    {FLOAT, "2e"},
     {EOF, ""},
   }
 
@@ -132,3 +209,318 @@ func TestAdvance(t *testing.T) {
     }
   }
 }
+
+func TestAdvanceString(t *testing.T) {
+  tests := []struct {
+    input           string
+    expectedLiteral string
+    expectedError   string
+  }{
+    {`""`, "", ""},
+    {`"a\nb"`, "a\nb", ""},
+    {`"a\tb"`, "a\tb", ""},
+    {`"say \"hi\""`, `say "hi"`, ""},
+    {`"back\\slash"`, `back\slash`, ""},
+    {`"\u{48}\u{49}"`, "HI", ""},
+    {`"\u{1F600}"`, "\U0001F600", ""},
+    {`"héllo"`, "héllo", ""},
+    {`"unterminated`, "unterminated", "1:1: unterminated string"},
+    {`"a\qb"`, "ab", `1:3: invalid escape \q`},
+    {`"\u{}"`, "", "1:2: invalid unicode escape"},
+    {`"\u{110000}"`, "", "1:2: invalid unicode escape"},
+    {`"\u{41"`, "", "1:2: invalid unicode escape"},
+    {`"a\`, "a", "1:1: unterminated string"},
+  }
+
+  for i, tt := range tests {
+    l := NewLexer(tt.input)
+    token := l.Advance()
+
+    if token.Kind != STRING {
+      t.Fatalf(
+        "tests[%d] - Wrong token kind: expected=%q, got=%q",
+        i,
+        STRING,
+        token.Kind,
+      )
+    }
+
+    if token.Literal != tt.expectedLiteral {
+      t.Fatalf(
+        "tests[%d] - Wrong literal: expected=%q, got=%q",
+        i,
+        tt.expectedLiteral,
+        token.Literal,
+      )
+    }
+
+    diagnostics := l.Diagnostics()
+
+    if tt.expectedError == "" {
+      if len(diagnostics) != 0 {
+        t.Errorf("tests[%d] - Unexpected error: %q", i, diagnostics[0])
+      }
+
+      continue
+    }
+
+    if len(diagnostics) != 1 {
+      t.Errorf("tests[%d] - Expected 1 error, got %d", i, len(diagnostics))
+      continue
+    }
+
+    if diagnostics[0].String() != tt.expectedError {
+      t.Errorf(
+        "tests[%d] - Wrong error: expected=%q, got=%q",
+        i,
+        tt.expectedError,
+        diagnostics[0].String(),
+      )
+    }
+  }
+}
+
+func TestAdvanceNumber(t *testing.T) {
+  tests := []struct {
+    input           string
+    expectedKind    TokenKind
+    expectedLiteral string
+    expectedError   string
+  }{
+    {"0xff", INT, "0xff", ""},
+    {"0XFF", INT, "0XFF", ""},
+    {"0o17", INT, "0o17", ""},
+    {"017", INT, "017", ""},
+    {"0b101", INT, "0b101", ""},
+    {"1_000_000", INT, "1_000_000", ""},
+    {"0x_ff_ff", INT, "0x_ff_ff", ""},
+    {"1_000.5e1_0", FLOAT, "1_000.5e1_0", ""},
+    {"0", INT, "0", ""},
+    {"0.5", FLOAT, "0.5", ""},
+    {"0xZZ", INT, "0xZZ", "1:3: invalid digit 'Z' in hexadecimal literal"},
+    {"0b102", INT, "0b102", "1:5: invalid digit '2' in binary literal"},
+    {"0o8", INT, "0o8", "1:3: invalid digit '8' in octal literal"},
+    {"09", INT, "09", "1:2: invalid digit '9' in octal literal"},
+    {"12abc", INT, "12abc", "1:3: invalid digit 'a' in decimal literal"},
+    {"0x", INT, "0x", "1:1: hexadecimal literal has no digits"},
+    {"1__0", INT, "1__0", "1:2: '_' must separate successive digits"},
+    {"1_", INT, "1_", "1:2: '_' must separate successive digits"},
+    {"1_.5", FLOAT, "1_.5", "1:2: '_' must separate successive digits"},
+    {"2e", FLOAT, "2e", "1:2: exponent has no digits"},
+    {"2e+x", FLOAT, "2e", "1:2: exponent has no digits"},
+    {"1e2e3", FLOAT, "1e2e3", "1:4: invalid digit 'e' in decimal literal"},
+  }
+
+  for i, tt := range tests {
+    l := NewLexer(tt.input)
+    token := l.Advance()
+
+    if token.Kind != tt.expectedKind {
+      t.Fatalf(
+        "tests[%d] - Wrong token kind: expected=%q, got=%q",
+        i,
+        tt.expectedKind,
+        token.Kind,
+      )
+    }
+
+    if token.Literal != tt.expectedLiteral {
+      t.Fatalf(
+        "tests[%d] - Wrong literal: expected=%q, got=%q",
+        i,
+        tt.expectedLiteral,
+        token.Literal,
+      )
+    }
+
+    diagnostics := l.Diagnostics()
+
+    if tt.expectedError == "" {
+      if len(diagnostics) != 0 {
+        t.Errorf("tests[%d] - Unexpected error: %q", i, diagnostics[0])
+      }
+
+      continue
+    }
+
+    if len(diagnostics) != 1 {
+      t.Errorf("tests[%d] - Expected 1 error, got %d", i, len(diagnostics))
+      continue
+    }
+
+    if diagnostics[0].String() != tt.expectedError {
+      t.Errorf(
+        "tests[%d] - Wrong error: expected=%q, got=%q",
+        i,
+        tt.expectedError,
+        diagnostics[0].String(),
+      )
+    }
+  }
+}
+
+func TestAdvanceComments(t *testing.T) {
+  input := `// leading
+let x = 1; // trailing
+/* block */ x /* nested /* comment */ still
+comment */ + 2 / 1
+/* unterminated /* `
+
+  tests := []struct {
+    expectedKind    TokenKind
+    expectedLiteral string
+  }{
+    {LET, "let"},
+    {IDENT, "x"},
+    {ASSIGN, "="},
+    {INT, "1"},
+    {SEMICOLON, ";"},
+    {IDENT, "x"},
+    {PLUS, "+"},
+    {INT, "2"},
+    {SLASH, "/"},
+    {INT, "1"},
+    {EOF, ""},
+  }
+
+  l := NewLexer(input)
+
+  for i, tt := range tests {
+    token := l.Advance()
+
+    if token.Kind != tt.expectedKind || token.Literal != tt.expectedLiteral {
+      t.Fatalf(
+        "tests[%d] - Wrong token: expected=%s %q, got=%s %q",
+        i,
+        tt.expectedKind,
+        tt.expectedLiteral,
+        token.Kind,
+        token.Literal,
+      )
+    }
+  }
+
+  comments := []Comment{
+    {"// leading", Span{Position{1, 1, 0}, Position{1, 11, 10}}},
+    {"// trailing", Span{Position{2, 12, 22}, Position{2, 23, 33}}},
+    {"/* block */", Span{Position{3, 1, 34}, Position{3, 12, 45}}},
+    {
+      "/* nested /* comment */ still\ncomment */",
+      Span{Position{3, 15, 48}, Position{4, 11, 88}},
+    },
+    {"/* unterminated /* ", Span{Position{5, 1, 97}, Position{5, 20, 116}}},
+  }
+
+  if len(l.Comments()) != len(comments) {
+    t.Fatalf("wrong number of comments. got=%d", len(l.Comments()))
+  }
+
+  for i, comment := range comments {
+    if l.Comments()[i] != comment {
+      t.Errorf(
+        "comments[%d] - expected=%+v, got=%+v",
+        i,
+        comment,
+        l.Comments()[i],
+      )
+    }
+  }
+
+  diagnostics := l.Diagnostics()
+
+  if len(diagnostics) != 1 ||
+    diagnostics[0].String() != "5:1: unterminated block comment" {
+    t.Errorf("wrong diagnostics. got=%v", diagnostics)
+  }
+}
+
+func TestAdvancePositions(t *testing.T) {
+  input := "let x = 5;\n  x + \"hi\";\n"
+
+  tests := []struct {
+    expectedKind     TokenKind
+    expectedPosition Position
+  }{
+    {LET, Position{Line: 1, Column: 1, Offset: 0}},
+    {IDENT, Position{Line: 1, Column: 5, Offset: 4}},
+    {ASSIGN, Position{Line: 1, Column: 7, Offset: 6}},
+    {INT, Position{Line: 1, Column: 9, Offset: 8}},
+    {SEMICOLON, Position{Line: 1, Column: 10, Offset: 9}},
+    {IDENT, Position{Line: 2, Column: 3, Offset: 13}},
+    {PLUS, Position{Line: 2, Column: 5, Offset: 15}},
+    {STRING, Position{Line: 2, Column: 7, Offset: 17}},
+    {SEMICOLON, Position{Line: 2, Column: 11, Offset: 21}},
+    {EOF, Position{Line: 3, Column: 1, Offset: 23}},
+  }
+
+  l := NewLexer(input)
+
+  for i, tt := range tests {
+    token := l.Advance()
+
+    if token.Kind != tt.expectedKind {
+      t.Fatalf(
+        "tests[%d] - Wrong token kind: expected=%q, got=%q",
+        i,
+        tt.expectedKind,
+        token.Kind,
+      )
+    }
+
+    if token.Position != tt.expectedPosition {
+      t.Fatalf(
+        "tests[%d] - Wrong position: expected=%+v, got=%+v",
+        i,
+        tt.expectedPosition,
+        token.Position,
+      )
+    }
+  }
+}
+
+func TestAdvanceUnicode(t *testing.T) {
+  input := "let größe = \"日本\"; 名前 + é €"
+
+  tests := []struct {
+    expectedKind     TokenKind
+    expectedLiteral  string
+    expectedPosition Position
+  }{
+    {LET, "let", Position{Line: 1, Column: 1, Offset: 0}},
+    {IDENT, "größe", Position{Line: 1, Column: 5, Offset: 4}},
+    {ASSIGN, "=", Position{Line: 1, Column: 11, Offset: 12}},
+    {STRING, "日本", Position{Line: 1, Column: 13, Offset: 14}},
+    {SEMICOLON, ";", Position{Line: 1, Column: 17, Offset: 22}},
+    {IDENT, "名前", Position{Line: 1, Column: 19, Offset: 24}},
+    {PLUS, "+", Position{Line: 1, Column: 22, Offset: 31}},
+    {IDENT, "é", Position{Line: 1, Column: 24, Offset: 33}},
+    {ILLEGAL, "€", Position{Line: 1, Column: 26, Offset: 36}},
+    {EOF, "", Position{Line: 1, Column: 27, Offset: 39}},
+  }
+
+  l := NewLexer(input)
+
+  for i, tt := range tests {
+    token := l.Advance()
+
+    if token.Kind != tt.expectedKind || token.Literal != tt.expectedLiteral {
+      t.Fatalf(
+        "tests[%d] - Wrong token: expected=%s %q, got=%s %q",
+        i,
+        tt.expectedKind,
+        tt.expectedLiteral,
+        token.Kind,
+        token.Literal,
+      )
+    }
+
+    if token.Position != tt.expectedPosition {
+      t.Fatalf(
+        "tests[%d] - Wrong position: expected=%+v, got=%+v",
+        i,
+        tt.expectedPosition,
+        token.Position,
+      )
+    }
+  }
+}
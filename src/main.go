@@ -1,41 +1,94 @@
 package main
 
 import (
+  "errors"
+  "flag"
   "fmt"
   "os"
   "strings"
 )
 
-func EvalFile(filename string) (Object, error) {
+func EvalFile(filename string, engine Engine) (Object, error) {
   data, err := os.ReadFile(filename)
   if err != nil {
     return nil, fmt.Errorf("error reading file: %w", err)
   }
 
-  env := NewEnvironment()
-  parser := NewParser(NewLexer(string(data)))
+  source := string(data)
+
+  parser := NewParser(NewLexer(source))
   program := parser.Parse()
 
-  if len(parser.Errors()) != 0 {
-    var errMsg strings.Builder
-    errMsg.WriteString(fmt.Sprintf("parser errors in file %s:\n", filename))
-    for _, msg := range parser.Errors() {
-      errMsg.WriteString(fmt.Sprintf("\t%s\n", msg))
+  if diagnostics := parser.Diagnostics(); len(diagnostics) != 0 {
+    return nil, &SourceError{
+      Filename:    filename,
+      Source:      source,
+      Diagnostics: diagnostics,
+    }
+  }
+
+  result := engine.Run(program)
+
+  if err, ok := result.(*Error); ok {
+    return nil, &SourceError{
+      Filename:    filename,
+      Source:      source,
+      Diagnostics: []Diagnostic{err.Diagnostic()},
     }
-    return nil, fmt.Errorf(errMsg.String())
   }
 
-  result := Eval(program, env)
   return result, nil
 }
 
 func main() {
-  args := os.Args[1:]
+  name := flag.String(
+    "engine",
+    ENGINE_EVAL,
+    fmt.Sprintf("execution engine, %q or %q", ENGINE_EVAL, ENGINE_VM),
+  )
+
+  arithmetic := flag.String(
+    "arithmetic",
+    string(ARITHMETIC_BIG),
+    fmt.Sprintf(
+      "integer overflow handling, %q, %q or %q",
+      ARITHMETIC_BIG,
+      ARITHMETIC_CHECKED,
+      ARITHMETIC_WRAPPING,
+    ),
+  )
+
+  maxDepth := flag.Int(
+    "max-depth",
+    DEFAULT_MAX_DEPTH,
+    fmt.Sprintf(
+      "maximum depth of nested function calls, from 1 to %d",
+      MAX_DEPTH_LIMIT,
+    ),
+  )
+
+  flag.Parse()
+
+  if err := checkMaxDepth(*maxDepth); err != nil {
+    fmt.Printf("Error: %s\n", err)
+    os.Exit(1)
+  }
+
+  engine, err := NewEngine(*name, Options{
+    Arithmetic: Arithmetic(*arithmetic),
+    MaxDepth:   *maxDepth,
+  })
+  if err != nil {
+    fmt.Printf("Error: %s\n", err)
+    os.Exit(1)
+  }
+
+  args := flag.Args()
 
   if len(args) == 0 {
     fmt.Println("Monk programming language REPL")
     fmt.Println("Type in commands to evaluate them")
-    Repl(os.Stdin, os.Stdout)
+    Repl(os.Stdin, os.Stdout, engine)
   } else {
     filename := args[0]
 
@@ -44,9 +97,18 @@ func main() {
       os.Exit(1)
     }
 
-    result, err := EvalFile(filename)
+    result, err := EvalFile(filename, engine)
     if err != nil {
-      fmt.Printf("Error: %s\n", err)
+      var sourceErr *SourceError
+      if errors.As(err, &sourceErr) {
+        NewRenderer(
+          sourceErr.Filename,
+          sourceErr.Source,
+          isTerminal(os.Stdout),
+        ).Render(os.Stdout, sourceErr.Diagnostics...)
+      } else {
+        fmt.Printf("Error: %s\n", err)
+      }
       os.Exit(1)
     }
 
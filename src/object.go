@@ -3,18 +3,33 @@ package main
 import (
   "bytes"
   "fmt"
+  "hash/fnv"
+  "math/big"
+  "sort"
+  "strconv"
   "strings"
 )
 
 type ObjectType string
 
 const (
+  ARRAY_OBJ        = "ARRAY"
+  BIGINT_OBJ       = "BIGINT"
   BOOLEAN_OBJ      = "BOOLEAN"
+  BREAK_OBJ        = "BREAK"
+  BUILTIN_OBJ      = "BUILTIN"
+  CELL_OBJ         = "CELL"
+  COMPILED_FN_OBJ  = "COMPILED_FUNCTION"
+  CONTINUE_OBJ     = "CONTINUE"
   INTEGER_OBJ      = "INTEGER"
+  ITERATOR_OBJ     = "ITERATOR"
   NULL_OBJ         = "NULL"
   RETURN_VALUE_OBJ = "RETURN_VALUE"
+  STRING_OBJ       = "STRING"
   ERROR_OBJ        = "ERROR"
+  FLOAT_OBJ        = "FLOAT"
   FUNCTION_OBJ     = "FUNCTION"
+  HASH_OBJ         = "HASH"
 )
 
 type Object interface {
@@ -22,6 +37,16 @@ type Object interface {
   Inspect() string
 }
 
+type HashKey struct {
+  Type  ObjectType
+  Value uint64
+}
+
+type Hashable interface {
+  Object
+  HashKey() HashKey
+}
+
 type Integer struct {
   Value int64
 }
@@ -34,6 +59,51 @@ func (i *Integer) Type() ObjectType {
   return INTEGER_OBJ
 }
 
+func (i *Integer) HashKey() HashKey {
+  return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// BigInt is an integer that does not fit in an Integer. Arithmetic moves
+// between the two as results grow and shrink, so a BigInt never holds a
+// value that an Integer could.
+type BigInt struct {
+  Value *big.Int
+}
+
+func (b *BigInt) Inspect() string {
+  return b.Value.String()
+}
+
+func (b *BigInt) Type() ObjectType {
+  return BIGINT_OBJ
+}
+
+func (b *BigInt) HashKey() HashKey {
+  h := fnv.New64a()
+  h.Write([]byte(b.Value.String()))
+  return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+type Float struct {
+  Value float64
+}
+
+// Inspect formats the float in its shortest exact form, keeping a decimal
+// point on whole numbers so that they read differently from integers.
+func (f *Float) Inspect() string {
+  s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+
+  if !strings.ContainsAny(s, ".eIN") {
+    s += ".0"
+  }
+
+  return s
+}
+
+func (f *Float) Type() ObjectType {
+  return FLOAT_OBJ
+}
+
 type Boolean struct {
   Value bool
 }
@@ -46,6 +116,34 @@ func (b *Boolean) Type() ObjectType {
   return BOOLEAN_OBJ
 }
 
+func (b *Boolean) HashKey() HashKey {
+  var value uint64
+
+  if b.Value {
+    value = 1
+  }
+
+  return HashKey{Type: b.Type(), Value: value}
+}
+
+type String struct {
+  Value string
+}
+
+func (s *String) Inspect() string {
+  return s.Value
+}
+
+func (s *String) Type() ObjectType {
+  return STRING_OBJ
+}
+
+func (s *String) HashKey() HashKey {
+  h := fnv.New64a()
+  h.Write([]byte(s.Value))
+  return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
 type Null struct{}
 
 func (n *Null) Inspect() string {
@@ -63,34 +161,290 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Break and Continue are the results of `break` and `continue` statements.
+// Like return values, they unwind the blocks enclosing them, stopping at
+// the innermost loop.
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
 type Error struct {
-  Message string
+  Message  string
+  Position Position
+  Trace    []StackFrame
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Error() string    { return e.Message }
+func (e *Error) Inspect() string {
+  if e.Position.IsValid() {
+    return fmt.Sprintf("ERROR: %s: %s", e.Position, e.Message)
+  }
+
+  return "ERROR: " + e.Message
+}
+
+// MAX_TRACE_NOTES bounds how many stack frames are listed when an error is
+// reported, so that runaway recursion does not bury the message.
+const MAX_TRACE_NOTES = 10
+
+func (e *Error) Diagnostic() Diagnostic {
+  d := errorDiagnostic(PositionSpan(e.Position), "%s", e.Message)
+
+  for i, frame := range e.Trace {
+    if i == MAX_TRACE_NOTES {
+      d.Notes = append(
+        d.Notes,
+        fmt.Sprintf("... and %d more calls", len(e.Trace)-i),
+      )
+      break
+    }
+
+    d.Notes = append(d.Notes, frame.String())
+  }
+
+  return d
+}
+
+// StackFrame is a function call that was in progress when an error was
+// raised, located at the position it was called from.
+type StackFrame struct {
+  Function string
+  Position Position
+}
+
+func (f StackFrame) String() string {
+  return fmt.Sprintf(
+    "in %s, called at %s",
+    describeFunction(f.Function),
+    f.Position,
+  )
+}
+
+// describeFunction names a function in messages, falling back to a
+// description for functions that were never bound to a name.
+func describeFunction(name string) string {
+  if name == "" {
+    return "anonymous function"
+  }
+
+  return "`" + name + "`"
+}
+
+// VARIADIC is the maximum of an arity that accepts any number of arguments
+// beyond its minimum.
+const VARIADIC = -1
+
+// Arity is the number of arguments a function accepts.
+type Arity struct {
+  Min int
+  Max int
+}
+
+func (a Arity) Accepts(count int) bool {
+  return count >= a.Min && (a.Max == VARIADIC || count <= a.Max)
+}
+
+func (a Arity) String() string {
+  switch {
+  case a.Max == VARIADIC:
+    return fmt.Sprintf("at least %d", a.Min)
+  case a.Max == a.Min:
+    return fmt.Sprintf("%d", a.Min)
+  case a.Max == a.Min+1:
+    return fmt.Sprintf("%d or %d", a.Min, a.Max)
+  default:
+    return fmt.Sprintf("%d to %d", a.Min, a.Max)
+  }
+}
 
 type Function struct {
+  Name       string
   Parameters []*Identifier
+  Defaults   map[string]Expression
+  Rest       *Identifier
   Body       *BlockStatement
   Env        *Environment
 }
 
+func (f *Function) Arity() Arity {
+  arity := Arity{
+    Min: len(f.Parameters) - len(f.Defaults),
+    Max: len(f.Parameters),
+  }
+
+  if f.Rest != nil {
+    arity.Max = VARIADIC
+  }
+
+  return arity
+}
+
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
 func (f *Function) Inspect() string {
+  return inspectFunction(f.Parameters, f.Defaults, f.Rest, f.Body)
+}
+
+// inspectFunction renders a function value. The virtual machine renders
+// its closures with it too, so both engines print functions alike.
+func inspectFunction(
+  parameters []*Identifier,
+  defaults map[string]Expression,
+  rest *Identifier,
+  body *BlockStatement,
+) string {
   var out bytes.Buffer
 
-  params := []string{}
-  for _, p := range f.Parameters {
-    params = append(params, p.String())
-  }
+  params := parameterStrings(parameters, defaults, rest)
 
   out.WriteString("fn")
   out.WriteString("(")
   out.WriteString(strings.Join(params, ", "))
   out.WriteString(") {\n")
-  out.WriteString(f.Body.String())
+  out.WriteString(body.String())
   out.WriteString("\n}")
 
   return out.String()
 }
+
+type BuiltinFunction func(args ...Object) Object
+
+// Builtin is a function implemented in Go. Its Fn is only called with a
+// number of arguments that its Arity accepts.
+type Builtin struct {
+  Name  string
+  Arity Arity
+  Fn    BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin function " + b.Name }
+
+type Array struct {
+  Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+  var out bytes.Buffer
+
+  elements := []string{}
+  for _, e := range a.Elements {
+    elements = append(elements, e.Inspect())
+  }
+
+  out.WriteString("[")
+  out.WriteString(strings.Join(elements, ", "))
+  out.WriteString("]")
+
+  return out.String()
+}
+
+type HashPair struct {
+  Key   Object
+  Value Object
+}
+
+type Hash struct {
+  Pairs map[HashKey]HashPair
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+  var out bytes.Buffer
+
+  pairs := []string{}
+  for _, pair := range h.Pairs {
+    pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+  }
+
+  sort.Strings(pairs)
+
+  out.WriteString("{")
+  out.WriteString(strings.Join(pairs, ", "))
+  out.WriteString("}")
+
+  return out.String()
+}
+
+type CompiledFunction struct {
+  Name          string
+  Instructions  Instructions
+  LocalNames    []string
+  FreeNames     []string
+  NumLocals     int
+  NumParameters int
+  NumDefaults   int
+  Variadic      bool
+  SourceMap     SourceMap
+  Source        string
+}
+
+func (cf *CompiledFunction) Arity() Arity {
+  arity := Arity{
+    Min: cf.NumParameters - cf.NumDefaults,
+    Max: cf.NumParameters,
+  }
+
+  if cf.Variadic {
+    arity.Max = VARIADIC
+  }
+
+  return arity
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FN_OBJ }
+func (cf *CompiledFunction) Inspect() string {
+  return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure is the virtual machine's runtime representation of a function
+// value. It reports itself as a FUNCTION so that programs observe the same
+// types regardless of which engine runs them.
+type Closure struct {
+  Fn   *CompiledFunction
+  Free []Object
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) Inspect() string  { return c.Fn.Source }
+
+// Cell holds a local variable of the virtual machine that a closure has
+// captured, so that assignments made by either of them are seen by both.
+type Cell struct {
+  Value Object
+}
+
+func (c *Cell) Type() ObjectType { return CELL_OBJ }
+func (c *Cell) Inspect() string  { return c.Value.Inspect() }
+
+// Iterator steps through the elements of an iterable value in a `for`
+// loop run by the virtual machine.
+type Iterator struct {
+  Elements []Object
+  index    int
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (it *Iterator) Inspect() string {
+  return fmt.Sprintf("Iterator[%p]", it)
+}
+
+// Next returns the next element, or false once every element has been
+// returned.
+func (it *Iterator) Next() (Object, bool) {
+  if it.index >= len(it.Elements) {
+    return nil, false
+  }
+
+  element := it.Elements[it.index]
+  it.index++
+
+  return element, true
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+  "math"
+  "testing"
+)
+
+func TestStringHashKey(t *testing.T) {
+  hello1 := &String{Value: "Hello World"}
+  hello2 := &String{Value: "Hello World"}
+  diff1 := &String{Value: "My name is johnny"}
+  diff2 := &String{Value: "My name is johnny"}
+
+  if hello1.HashKey() != hello2.HashKey() {
+    t.Errorf("strings with same content have different hash keys")
+  }
+
+  if diff1.HashKey() != diff2.HashKey() {
+    t.Errorf("strings with same content have different hash keys")
+  }
+
+  if hello1.HashKey() == diff1.HashKey() {
+    t.Errorf("strings with different content have same hash keys")
+  }
+}
+
+func TestHashKeyDistinguishesTypes(t *testing.T) {
+  one := &Integer{Value: 1}
+
+  if one.HashKey() == TRUE_LIT.HashKey() {
+    t.Errorf("integer 1 and true have the same hash key")
+  }
+}
+
+func TestFloatInspect(t *testing.T) {
+  tests := []struct {
+    value    float64
+    expected string
+  }{
+    {1.5, "1.5"},
+    {3, "3.0"},
+    {-0.25, "-0.25"},
+    {1e21, "1e+21"},
+    {1.5e-7, "1.5e-07"},
+    {math.Inf(1), "+Inf"},
+    {math.NaN(), "NaN"},
+  }
+
+  for _, tt := range tests {
+    if actual := (&Float{Value: tt.value}).Inspect(); actual != tt.expected {
+      t.Errorf("wrong inspect for %g. want=%q, got=%q",
+        tt.value, tt.expected, actual)
+    }
+  }
+}
+
+func TestHashInspect(t *testing.T) {
+  hash := &Hash{Pairs: map[HashKey]HashPair{}}
+
+  for i, key := range []string{"b", "a", "c"} {
+    str := &String{Value: key}
+    hash.Pairs[str.HashKey()] = HashPair{
+      Key:   str,
+      Value: &Integer{Value: int64(i)},
+    }
+  }
+
+  if hash.Inspect() != "{a: 1, b: 0, c: 2}" {
+    t.Errorf("hash.Inspect() wrong. got=%q", hash.Inspect())
+  }
+}
+
+func TestErrorDiagnosticTrace(t *testing.T) {
+  err := &Error{
+    Message:  "type mismatch: INTEGER + BOOLEAN",
+    Position: Position{Line: 2, Column: 5},
+  }
+
+  for i := 0; i < MAX_TRACE_NOTES+3; i++ {
+    err.Trace = append(err.Trace, StackFrame{
+      Function: "f",
+      Position: Position{Line: 4, Column: 2},
+    })
+  }
+
+  err.Trace[1].Function = ""
+
+  notes := err.Diagnostic().Notes
+
+  if len(notes) != MAX_TRACE_NOTES+1 {
+    t.Fatalf("wrong number of notes. got=%d", len(notes))
+  }
+
+  expected := map[int]string{
+    0:               "in `f`, called at 4:2",
+    1:               "in anonymous function, called at 4:2",
+    MAX_TRACE_NOTES: "... and 3 more calls",
+  }
+
+  for i, note := range expected {
+    if notes[i] != note {
+      t.Errorf("wrong note %d. want=%q, got=%q", i, note, notes[i])
+    }
+  }
+}
+
+func TestArity(t *testing.T) {
+  tests := []struct {
+    arity    Arity
+    expected string
+    accepts  []int
+    rejects  []int
+  }{
+    {Arity{1, 1}, "1", []int{1}, []int{0, 2}},
+    {Arity{0, 1}, "0 or 1", []int{0, 1}, []int{2}},
+    {Arity{1, 3}, "1 to 3", []int{1, 2, 3}, []int{0, 4}},
+    {Arity{2, VARIADIC}, "at least 2", []int{2, 3, 100}, []int{0, 1}},
+  }
+
+  for _, tt := range tests {
+    if tt.arity.String() != tt.expected {
+      t.Errorf("wrong string. want=%q, got=%q", tt.expected, tt.arity)
+    }
+
+    for _, count := range tt.accepts {
+      if !tt.arity.Accepts(count) {
+        t.Errorf("%s does not accept %d", tt.arity, count)
+      }
+    }
+
+    for _, count := range tt.rejects {
+      if tt.arity.Accepts(count) {
+        t.Errorf("%s accepts %d", tt.arity, count)
+      }
+    }
+  }
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
-  "fmt"
+  "errors"
+  "math/big"
+  "sort"
   "strconv"
 )
 
@@ -13,59 +15,112 @@ type (
 const (
   _ int = iota
   LOWEST
+  ASSIGNMENT
+  LOGICAL_OR
+  LOGICAL_AND
+  BITWISE_OR
+  BITWISE_XOR
+  BITWISE_AND
   EQUALS
   LESSGREATER
+  SHIFT
   SUM
   PRODUCT
+  EXPONENT
   PREFIX
   CALL
+  INDEX
 )
 
 var precedences = map[TokenKind]int{
-  ASTERISK: PRODUCT,
-  EQ:       EQUALS,
-  GT:       LESSGREATER,
-  LPAREN:   CALL,
-  LT:       LESSGREATER,
-  MINUS:    SUM,
-  NOT_EQ:   EQUALS,
-  PLUS:     SUM,
-  SLASH:    PRODUCT,
+  AMPERSAND:       BITWISE_AND,
+  AND:             LOGICAL_AND,
+  ASSIGN:          ASSIGNMENT,
+  ASTERISK:        PRODUCT,
+  ASTERISK_ASSIGN: ASSIGNMENT,
+  CARET:           BITWISE_XOR,
+  EQ:              EQUALS,
+  GT:              LESSGREATER,
+  GT_EQ:           LESSGREATER,
+  LBRACKET:        INDEX,
+  LPAREN:          CALL,
+  LT:              LESSGREATER,
+  LT_EQ:           LESSGREATER,
+  MINUS:           SUM,
+  MINUS_ASSIGN:    ASSIGNMENT,
+  NOT_EQ:          EQUALS,
+  OR:              LOGICAL_OR,
+  PERCENT:         PRODUCT,
+  PIPE:            BITWISE_OR,
+  PLUS:            SUM,
+  PLUS_ASSIGN:     ASSIGNMENT,
+  POWER:           EXPONENT,
+  SHIFT_LEFT:      SHIFT,
+  SHIFT_RIGHT:     SHIFT,
+  SLASH:           PRODUCT,
+  SLASH_ASSIGN:    ASSIGNMENT,
 }
 
 type Parser struct {
-  curr   Token
-  errors []string
-  infix  map[TokenKind]infixParseFn
-  lexer  *Lexer
-  peek   Token
-  prefix map[TokenKind]prefixParseFn
+  block       int
+  curr        Token
+  depth       int
+  diagnostics []Diagnostic
+  infix       map[TokenKind]infixParseFn
+  lexer       *Lexer
+  loops       int
+  peek        Token
+  prefix      map[TokenKind]prefixParseFn
+  recovering  bool
+  unexpected  Token
 }
 
 func NewParser(lexer *Lexer) *Parser {
-  p := &Parser{lexer: lexer, errors: []string{}}
+  p := &Parser{lexer: lexer, diagnostics: []Diagnostic{}}
 
   p.prefix = make(map[TokenKind]prefixParseFn)
   p.registerPrefix(BANG, p.parsePrefixExpression)
   p.registerPrefix(FALSE, p.parseBoolean)
+  p.registerPrefix(FLOAT, p.parseFloatLiteral)
   p.registerPrefix(FUNCTION, p.parseFunctionLiteral)
   p.registerPrefix(IDENT, p.parseIdentifier)
   p.registerPrefix(IF, p.parseIfExpression)
   p.registerPrefix(INT, p.parseIntegerLiteral)
+  p.registerPrefix(LBRACE, p.parseHashLiteral)
+  p.registerPrefix(LBRACKET, p.parseArrayLiteral)
   p.registerPrefix(LPAREN, p.parseGroupedExpression)
   p.registerPrefix(MINUS, p.parsePrefixExpression)
+  p.registerPrefix(STRING, p.parseStringLiteral)
+  p.registerPrefix(TILDE, p.parsePrefixExpression)
   p.registerPrefix(TRUE, p.parseBoolean)
 
   p.infix = make(map[TokenKind]infixParseFn)
+  p.registerInfix(AMPERSAND, p.parseInfixExpression)
+  p.registerInfix(AND, p.parseInfixExpression)
+  p.registerInfix(ASSIGN, p.parseAssignExpression)
   p.registerInfix(ASTERISK, p.parseInfixExpression)
+  p.registerInfix(ASTERISK_ASSIGN, p.parseAssignExpression)
+  p.registerInfix(CARET, p.parseInfixExpression)
   p.registerInfix(EQ, p.parseInfixExpression)
   p.registerInfix(GT, p.parseInfixExpression)
+  p.registerInfix(GT_EQ, p.parseInfixExpression)
+  p.registerInfix(LBRACKET, p.parseIndexExpression)
   p.registerInfix(LPAREN, p.parseCallExpression)
   p.registerInfix(LT, p.parseInfixExpression)
+  p.registerInfix(LT_EQ, p.parseInfixExpression)
   p.registerInfix(MINUS, p.parseInfixExpression)
+  p.registerInfix(MINUS_ASSIGN, p.parseAssignExpression)
   p.registerInfix(NOT_EQ, p.parseInfixExpression)
+  p.registerInfix(OR, p.parseInfixExpression)
+  p.registerInfix(PERCENT, p.parseInfixExpression)
+  p.registerInfix(PIPE, p.parseInfixExpression)
   p.registerInfix(PLUS, p.parseInfixExpression)
+  p.registerInfix(PLUS_ASSIGN, p.parseAssignExpression)
+  p.registerInfix(POWER, p.parseInfixExpression)
+  p.registerInfix(SHIFT_LEFT, p.parseInfixExpression)
+  p.registerInfix(SHIFT_RIGHT, p.parseInfixExpression)
   p.registerInfix(SLASH, p.parseInfixExpression)
+  p.registerInfix(SLASH_ASSIGN, p.parseAssignExpression)
 
   p.advance()
   p.advance()
@@ -73,24 +128,33 @@ func NewParser(lexer *Lexer) *Parser {
   return p
 }
 
-func (p *Parser) Errors() []string {
-  return p.errors
+// Diagnostics returns the errors found by the lexer and the parser, in
+// source order.
+func (p *Parser) Diagnostics() []Diagnostic {
+  diagnostics := append([]Diagnostic{}, p.lexer.Diagnostics()...)
+  diagnostics = append(diagnostics, p.diagnostics...)
+
+  sort.SliceStable(diagnostics, func(i, j int) bool {
+    return diagnostics[i].Span.Start.Offset < diagnostics[j].Span.Start.Offset
+  })
+
+  return diagnostics
 }
 
-func (p *Parser) Parse() *Program {
-  program := &Program{}
+func (p *Parser) Errors() []string {
+  errors := []string{}
 
-  program.Statements = []Statement{}
+  for _, d := range p.Diagnostics() {
+    errors = append(errors, d.String())
+  }
 
-  for p.curr.Kind != EOF {
-    statement := p.parseStatement()
+  return errors
+}
 
-    if statement != nil {
-      program.Statements = append(program.Statements, statement)
-    }
+func (p *Parser) Parse() *Program {
+  program := &Program{}
 
-    p.advance()
-  }
+  program.Statements = p.parseStatementList(EOF)
 
   return program
 }
@@ -106,6 +170,13 @@ func (p *Parser) registerInfix(kind TokenKind, fn infixParseFn) {
 func (p *Parser) advance() {
   p.curr = p.peek
   p.peek = p.lexer.Advance()
+
+  switch p.curr.Kind {
+  case LBRACE:
+    p.depth++
+  case RBRACE:
+    p.depth--
+  }
 }
 
 func (p *Parser) expectPeek(kind TokenKind) bool {
@@ -134,37 +205,153 @@ func (p *Parser) currPrecedence() int {
   return LOWEST
 }
 
-func (p *Parser) peekError(kind TokenKind) {
-  p.errors = append(
-    p.errors,
-    fmt.Sprintf(
-      "Expected next token to be %s but got %s instead",
-      kind,
-      p.peek.Kind,
-    ),
+// error records a diagnostic at token. Once a statement has failed to
+// parse, further errors are likely to be fallout from the first one, so
+// they are suppressed until the parser has synchronized.
+func (p *Parser) error(token Token, format string, a ...interface{}) {
+  if p.recovering {
+    return
+  }
+
+  p.recovering = true
+  p.unexpected = token
+
+  p.diagnostics = append(
+    p.diagnostics,
+    errorDiagnostic(TokenSpan(token), format, a...),
   )
 }
 
-func (p *Parser) missingPrefixError(kind TokenKind) {
-  p.errors = append(
-    p.errors,
-    fmt.Sprintf("No prefix parse function for %s found", kind),
+// synchronize skips the remainder of a statement that failed to parse. It
+// stops on the statement's terminating semicolon, or before the next
+// statement keyword or closing brace, skipping over any braces the
+// statement opened so that the enclosing block is not cut short.
+//
+// If the parser is still on the unexpected token, and that token begins a
+// new statement or closes the enclosing block, synchronize stops on it and
+// returns true, telling the caller not to advance past it.
+func (p *Parser) synchronize(start Token) bool {
+  p.recovering = false
+
+  if p.curr == p.unexpected && p.curr != start {
+    if p.closesBlock(p.curr, p.depth+1) || startsStatement(p.curr.Kind) {
+      return true
+    }
+  }
+
+  depth := 0
+
+  for p.peek.Kind != EOF {
+    switch p.curr.Kind {
+    case LBRACE:
+      depth++
+    case RBRACE:
+      if depth > 0 {
+        depth--
+      }
+    }
+
+    if depth == 0 && p.atStatementBoundary() {
+      return false
+    }
+
+    p.advance()
+  }
+
+  return false
+}
+
+func (p *Parser) atStatementBoundary() bool {
+  if p.curr.Kind == SEMICOLON {
+    return true
+  }
+
+  return p.closesBlock(p.peek, p.depth) || startsStatement(p.peek.Kind)
+}
+
+// closesBlock reports whether token, read at the given brace depth, is the
+// brace closing the block being parsed, rather than one closing a hash
+// literal or function body nested inside the current statement.
+func (p *Parser) closesBlock(token Token, depth int) bool {
+  return token.Kind == RBRACE && p.block > 0 && depth == p.block
+}
+
+// startsStatement reports whether kind is a keyword that begins a
+// statement rather than an expression.
+func startsStatement(kind TokenKind) bool {
+  switch kind {
+  case BREAK, CONST, CONTINUE, FOR, LET, RETURN, WHILE:
+    return true
+  default:
+    return false
+  }
+}
+
+func (p *Parser) peekError(kind TokenKind) {
+  p.error(
+    p.peek,
+    "Expected next token to be %s but got %s instead",
+    kind,
+    p.peek.Kind,
   )
 }
 
+func (p *Parser) missingPrefixError(token Token) {
+  p.error(token, "No prefix parse function for %s found", token.Kind)
+}
+
 func (p *Parser) parseStatement() Statement {
   switch p.curr.Kind {
-  case LET:
+  case CONST, LET:
     return p.parseLetStatement()
   case RETURN:
     return p.parseReturnStatement()
+  case WHILE:
+    return p.parseWhileStatement()
+  case FOR:
+    return p.parseForStatement()
+  case BREAK, CONTINUE:
+    return p.parseLoopControlStatement()
   default:
     return p.parseExpressionStatement()
   }
 }
 
+// parseStatementList parses statements up to the end token, leaving out
+// any statement that fails to parse and resynchronizing after it.
+func (p *Parser) parseStatementList(end TokenKind) []Statement {
+  statements := []Statement{}
+
+  for p.curr.Kind != end && p.curr.Kind != EOF {
+    start, recovering := p.curr, p.recovering
+
+    statement := p.parseStatement()
+
+    if p.recovering && !recovering {
+      if p.synchronize(start) {
+        continue
+      }
+    } else if statement != nil {
+      statements = append(statements, statement)
+    }
+
+    p.advance()
+  }
+
+  return statements
+}
+
+// endStatement consumes the optional semicolon ending a statement. After
+// a failed statement the semicolon is left for synchronize, so parsing
+// never resumes past a token that closes the enclosing block.
+func (p *Parser) endStatement() {
+  if p.peek.Kind == SEMICOLON && !p.recovering {
+    p.advance()
+  }
+}
+
 func (p *Parser) parseLetStatement() *LetStatement {
-  statement := &LetStatement{Token: p.curr}
+  statement := &LetStatement{Token: p.curr, Constant: p.curr.Kind == CONST}
 
   if !p.expectPeek(IDENT) {
     return nil
@@ -180,10 +367,12 @@ func (p *Parser) parseLetStatement() *LetStatement {
 
   statement.Value = p.parseExpression(LOWEST)
 
-  if p.peek.Kind == SEMICOLON {
-    p.advance()
+  if function, ok := statement.Value.(*FunctionLiteral); ok {
+    function.Name = statement.Name.Value
   }
 
+  p.endStatement()
+
   return statement
 }
 
@@ -194,18 +383,104 @@ func (p *Parser) parseReturnStatement() *ReturnStatement {
 
   statement.ReturnValue = p.parseExpression(LOWEST)
 
-  if p.peek.Kind == SEMICOLON {
-    p.advance()
+  p.endStatement()
+
+  return statement
+}
+
+func (p *Parser) parseWhileStatement() *WhileStatement {
+  statement := &WhileStatement{Token: p.curr}
+
+  if !p.expectPeek(LPAREN) {
+    return nil
+  }
+
+  p.advance()
+
+  statement.Condition = p.parseExpression(LOWEST)
+
+  if !p.expectPeek(RPAREN) {
+    return nil
+  }
+
+  if !p.expectPeek(LBRACE) {
+    return nil
+  }
+
+  statement.Body = p.parseLoopBody()
+
+  p.endStatement()
+
+  return statement
+}
+
+func (p *Parser) parseForStatement() *ForStatement {
+  statement := &ForStatement{Token: p.curr}
+
+  if !p.expectPeek(LPAREN) {
+    return nil
+  }
+
+  if !p.expectPeek(IDENT) {
+    return nil
+  }
+
+  statement.Variable = &Identifier{Token: p.curr, Value: p.curr.Literal}
+
+  if !p.expectPeek(IN) {
+    return nil
   }
 
+  p.advance()
+
+  statement.Iterable = p.parseExpression(LOWEST)
+
+  if !p.expectPeek(RPAREN) {
+    return nil
+  }
+
+  if !p.expectPeek(LBRACE) {
+    return nil
+  }
+
+  statement.Body = p.parseLoopBody()
+
+  p.endStatement()
+
   return statement
 }
 
+func (p *Parser) parseLoopBody() *BlockStatement {
+  p.loops++
+  defer func() { p.loops-- }()
+
+  return p.parseBlockStatement()
+}
+
+// parseLoopControlStatement parses `break` and `continue`, which may only
+// appear inside the body of a loop in the same function.
+func (p *Parser) parseLoopControlStatement() Statement {
+  token := p.curr
+
+  if p.loops == 0 {
+    p.error(token, "Unexpected %s outside of a loop", token.Literal)
+    return nil
+  }
+
+  p.endStatement()
+
+  if token.Kind == BREAK {
+    return &BreakStatement{Token: token}
+  }
+
+  return &ContinueStatement{Token: token}
+}
+
 func (p *Parser) parseExpression(precedence int) Expression {
   prefix := p.prefix[p.curr.Kind]
 
   if prefix == nil {
-    p.missingPrefixError(p.curr.Kind)
+    p.missingPrefixError(p.curr)
     return nil
   }
 
@@ -231,9 +506,7 @@ func (p *Parser) parseExpressionStatement() *ExpressionStatement {
 
   statement.Expression = p.parseExpression(LOWEST)
 
-  if p.peek.Kind == SEMICOLON {
-    p.advance()
-  }
+  p.endStatement()
 
   return statement
 }
@@ -245,11 +518,19 @@ func (p *Parser) parseIdentifier() Expression {
 func (p *Parser) parseIntegerLiteral() Expression {
   literal := &IntegerLiteral{Token: p.curr}
 
+  if malformedNumber(p.curr.Literal) {
+    return nil
+  }
+
   value, err := strconv.ParseInt(p.curr.Literal, 0, 64)
 
+  if errors.Is(err, strconv.ErrRange) {
+    literal.Big, _ = new(big.Int).SetString(p.curr.Literal, 0)
+    return literal
+  }
+
   if err != nil {
-    msg := fmt.Sprintf("Could not parse %q as integer", p.curr.Literal)
-    p.errors = append(p.errors, msg)
+    p.error(p.curr, "Could not parse %q as integer", p.curr.Literal)
     return nil
   }
 
@@ -258,6 +539,36 @@ func (p *Parser) parseIntegerLiteral() Expression {
   return literal
 }
 
+// malformedNumber reports whether a numeric literal is one the lexer has
+// already reported as malformed, so that it is not reported twice.
+func malformedNumber(literal string) bool {
+  _, message := checkNumber(literal)
+  return message != ""
+}
+
+func (p *Parser) parseFloatLiteral() Expression {
+  literal := &FloatLiteral{Token: p.curr}
+
+  if malformedNumber(p.curr.Literal) {
+    return nil
+  }
+
+  value, err := strconv.ParseFloat(p.curr.Literal, 64)
+
+  if err != nil {
+    p.error(p.curr, "Could not parse %q as float", p.curr.Literal)
+    return nil
+  }
+
+  literal.Value = value
+
+  return literal
+}
+
+func (p *Parser) parseStringLiteral() Expression {
+  return &StringLiteral{Token: p.curr, Value: p.curr.Literal}
+}
+
 func (p *Parser) parseFunctionLiteral() Expression {
   literal := &FunctionLiteral{Token: p.curr}
 
@@ -265,7 +576,15 @@ func (p *Parser) parseFunctionLiteral() Expression {
     return nil
   }
 
-  literal.Parameters = p.parseFunctionParameters()
+  // Loops do not extend into the functions defined inside them, neither
+  // their default values nor their bodies.
+  loops := p.loops
+  p.loops = 0
+  defer func() { p.loops = loops }()
+
+  if !p.parseFunctionParameters(literal) {
+    return nil
+  }
 
   if !p.expectPeek(LBRACE) {
     return nil
@@ -276,35 +595,66 @@ func (p *Parser) parseFunctionLiteral() Expression {
   return literal
 }
 
-func (p *Parser) parseFunctionParameters() []*Identifier {
-  identifers := []*Identifier{}
+// parseFunctionParameters parses the parameter list of literal. Optional
+// parameters, which have default values, must come after the required
+// ones, and a rest parameter may only come last.
+func (p *Parser) parseFunctionParameters(literal *FunctionLiteral) bool {
+  literal.Parameters = []*Identifier{}
+  literal.Defaults = map[string]Expression{}
 
   if p.peek.Kind == RPAREN {
     p.advance()
-    return identifers
+    return true
+  }
+
+  parameter := func() bool {
+    if p.curr.Kind == ELLIPSIS {
+      if !p.expectPeek(IDENT) {
+        return false
+      }
+      literal.Rest = &Identifier{Token: p.curr, Value: p.curr.Literal}
+      return true
+    }
+
+    identifier := &Identifier{Token: p.curr, Value: p.curr.Literal}
+    literal.Parameters = append(literal.Parameters, identifier)
+
+    if p.peek.Kind == ASSIGN {
+      p.advance()
+      p.advance()
+      literal.Defaults[identifier.Value] = p.parseExpression(LOWEST)
+    } else if len(literal.Defaults) != 0 {
+      p.error(
+        identifier.Token,
+        "Required parameter %s follows a parameter with a default value",
+        identifier.Value,
+      )
+    }
+
+    return true
   }
 
   p.advance()
 
-  identifers = append(
-    identifers,
-    &Identifier{Token: p.curr, Value: p.curr.Literal},
-  )
+  if !parameter() {
+    return false
+  }
 
-  for p.peek.Kind == COMMA {
+  for p.peek.Kind == COMMA && literal.Rest == nil {
     p.advance()
     p.advance()
-    identifers = append(
-      identifers,
-      &Identifier{Token: p.curr, Value: p.curr.Literal},
-    )
+
+    if !parameter() {
+      return false
+    }
   }
 
-  if !p.expectPeek(RPAREN) {
-    return nil
+  if literal.Rest != nil && p.peek.Kind == COMMA {
+    p.error(p.peek, "Rest parameter %s must be last", literal.Rest.Value)
+    return false
   }
 
-  return identifers
+  return p.expectPeek(RPAREN)
 }
 
 func (p *Parser) parsePrefixExpression() Expression {
@@ -329,6 +679,11 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
 
   precedence := p.currPrecedence()
 
+  // Exponentiation is right associative, so `2 ** 3 ** 2` is `2 ** 9`.
+  if p.curr.Kind == POWER {
+    precedence--
+  }
+
   p.advance()
 
   expression.Right = p.parseExpression(precedence)
@@ -336,6 +691,26 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
   return expression
 }
 
+// parseAssignExpression parses an assignment to the variable named by
+// left. Assignments are right associative, so `a = b = 1` assigns to both.
+func (p *Parser) parseAssignExpression(left Expression) Expression {
+  expression := &AssignExpression{Token: p.curr, Operator: p.curr.Literal}
+
+  name, ok := left.(*Identifier)
+  if !ok {
+    p.error(p.curr, "Cannot assign to %s", left)
+    return nil
+  }
+
+  expression.Name = name
+
+  p.advance()
+
+  expression.Value = p.parseExpression(ASSIGNMENT - 1)
+
+  return expression
+}
+
 func (p *Parser) parseBoolean() Expression {
   return &BooleanExpression{Token: p.curr, Value: p.curr.Kind == TRUE}
 }
@@ -389,49 +764,137 @@ func (p *Parser) parseIfExpression() Expression {
 func (p *Parser) parseBlockStatement() *BlockStatement {
   block := &BlockStatement{Token: p.curr}
 
-  block.Statements = []Statement{}
+  outer := p.block
+  p.block = p.depth
 
   p.advance()
 
-  for p.curr.Kind != RBRACE && p.curr.Kind != EOF {
-    statement := p.parseStatement()
+  block.Statements = p.parseStatementList(RBRACE)
+
+  p.block = outer
+
+  return block
+}
+
+func (p *Parser) parseCallExpression(function Expression) Expression {
+  exp := &CallExpression{Token: p.curr, Function: function}
+
+  exp.Arguments = p.parseExpressionList(RPAREN)
+
+  if exp.Arguments == nil {
+    return nil
+  }
+
+  return exp
+}
+
+// parseSpreadExpression parses `...value`, which may only appear as an
+// argument of a call.
+func (p *Parser) parseSpreadExpression() Expression {
+  spread := &SpreadExpression{Token: p.curr}
+
+  p.advance()
+
+  spread.Value = p.parseExpression(LOWEST)
+
+  return spread
+}
+
+func (p *Parser) parseArrayLiteral() Expression {
+  array := &ArrayLiteral{Token: p.curr}
+
+  array.Elements = p.parseExpressionList(RBRACKET)
+
+  if array.Elements == nil {
+    return nil
+  }
+
+  return array
+}
+
+// parseHashLiteral parses `{key: value, ...}`. Block statements are only
+// parsed where the grammar demands one (after `if`, `else` and `fn(...)`),
+// so a brace in expression position always starts a hash literal.
+func (p *Parser) parseHashLiteral() Expression {
+  hash := &HashLiteral{Token: p.curr}
+
+  hash.Keys = []Expression{}
+  hash.Values = []Expression{}
+
+  for p.peek.Kind != RBRACE {
+    p.advance()
+
+    key := p.parseExpression(LOWEST)
 
-    if statement != nil {
-      block.Statements = append(block.Statements, statement)
+    if !p.expectPeek(COLON) {
+      return nil
     }
 
     p.advance()
+
+    value := p.parseExpression(LOWEST)
+
+    hash.Keys = append(hash.Keys, key)
+    hash.Values = append(hash.Values, value)
+
+    if p.peek.Kind != RBRACE && !p.expectPeek(COMMA) {
+      return nil
+    }
   }
 
-  return block
+  if !p.expectPeek(RBRACE) {
+    return nil
+  }
+
+  return hash
 }
 
-func (p *Parser) parseCallExpression(function Expression) Expression {
-  exp := &CallExpression{Token: p.curr, Function: function}
+func (p *Parser) parseIndexExpression(left Expression) Expression {
+  exp := &IndexExpression{Token: p.curr, Left: left}
 
-  args := []Expression{}
+  p.advance()
 
-  if p.peek.Kind == RPAREN {
+  exp.Index = p.parseExpression(LOWEST)
+
+  if !p.expectPeek(RBRACKET) {
+    return nil
+  }
+
+  return exp
+}
+
+// parseExpressionList parses a comma separated list of expressions up to
+// end. The arguments of a call, which end in a closing parenthesis, may be
+// spread.
+func (p *Parser) parseExpressionList(end TokenKind) []Expression {
+  list := []Expression{}
+
+  if p.peek.Kind == end {
     p.advance()
-    exp.Arguments = args
-    return exp
+    return list
+  }
+
+  element := func() Expression {
+    if end == RPAREN && p.curr.Kind == ELLIPSIS {
+      return p.parseSpreadExpression()
+    }
+
+    return p.parseExpression(LOWEST)
   }
 
   p.advance()
 
-  args = append(args, p.parseExpression(LOWEST))
+  list = append(list, element())
 
   for p.peek.Kind == COMMA {
     p.advance()
     p.advance()
-    args = append(args, p.parseExpression(LOWEST))
+    list = append(list, element())
   }
 
-  if !p.expectPeek(RPAREN) {
+  if !p.expectPeek(end) {
     return nil
   }
 
-  exp.Arguments = args
-
-  return exp
+  return list
 }
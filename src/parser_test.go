@@ -76,6 +76,40 @@ func TestLetStatements(t *testing.T) {
   }
 }
 
+func TestConstStatement(t *testing.T) {
+  program := setup(t, "const x = 5; let y = x;")
+
+  tests := []struct {
+    expectedIdentifier string
+    expectedConstant   bool
+  }{
+    {"x", true},
+    {"y", false},
+  }
+
+  for i, tt := range tests {
+    statement, ok := program.Statements[i].(*LetStatement)
+
+    if !ok {
+      t.Fatalf("statement not *LetStatement, got=%T", program.Statements[i])
+    }
+
+    if statement.Name.Value != tt.expectedIdentifier {
+      t.Errorf("wrong name. want=%s, got=%s",
+        tt.expectedIdentifier, statement.Name.Value)
+    }
+
+    if statement.Constant != tt.expectedConstant {
+      t.Errorf("%s: wrong constant. want=%t, got=%t",
+        tt.expectedIdentifier, tt.expectedConstant, statement.Constant)
+    }
+  }
+
+  if program.String() != "const x = 5;let y = x;" {
+    t.Errorf("wrong program string. got=%q", program.String())
+  }
+}
+
 func TestReturnStatement(t *testing.T) {
   input := `
     return 5;
@@ -203,6 +237,65 @@ func TestIntegerLiteralExpression(t *testing.T) {
   }
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected float64
+  }{
+    {"1.5", 1.5},
+    {"0.25", 0.25},
+    {"1.5e3", 1500},
+    {"2E-2", 0.02},
+    {"3e+2", 300},
+  }
+
+  for _, tt := range tests {
+    program := setup(t, tt.input)
+
+    statement := program.Statements[0].(*ExpressionStatement)
+
+    literal, ok := statement.Expression.(*FloatLiteral)
+
+    if !ok {
+      t.Fatalf(
+        "Expression is not a *FloatLiteral, got=%T",
+        statement.Expression,
+      )
+    }
+
+    if literal.Value != tt.expected {
+      t.Errorf("literal.Value not %g, got=%g", tt.expected, literal.Value)
+    }
+
+    if literal.String() != tt.input {
+      t.Errorf("literal.String() not %s, got=%s", tt.input, literal.String())
+    }
+  }
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+  program := setup(t, `"hello world";`)
+
+  statement := program.Statements[0].(*ExpressionStatement)
+
+  literal, ok := statement.Expression.(*StringLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "Expression is not a *StringLiteral, got=%T",
+      statement.Expression,
+    )
+  }
+
+  if literal.Value != "hello world" {
+    t.Errorf("literal.Value not %q, got=%q", "hello world", literal.Value)
+  }
+
+  if literal.String() != `"hello world"` {
+    t.Errorf("literal.String() not %q, got=%q", `"hello world"`, literal)
+  }
+}
+
 func TestPrefixExpressions(t *testing.T) {
   prefixTests := []struct {
     input    string
@@ -380,6 +473,31 @@ func TestOperatorPrecdence(t *testing.T) {
       "add(a + b + c * d / f + g)",
       "add((((a + b) + ((c * d) / f)) + g))",
     },
+    {
+      "a * [1, 2, 3, 4][b * c] * d",
+      "((a * ([1, 2, 3, 4][(b * c)])) * d)",
+    },
+    {
+      "add(a * b[2], b[1], 2 * [1, 2][1])",
+      "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+    },
+    {"a <= b == b >= a", "((a <= b) == (b >= a))"},
+    {"a + b % c", "(a + (b % c))"},
+    {"a * b ** c", "(a * (b ** c))"},
+    {"a ** b ** c", "(a ** (b ** c))"},
+    {"-a ** b", "((-a) ** b)"},
+    {"a | b ^ c & d", "(a | (b ^ (c & d)))"},
+    {"a & b == c", "(a & (b == c))"},
+    {"a << b + c < d >> e", "((a << (b + c)) < (d >> e))"},
+    {"a && b | c", "(a && (b | c))"},
+    {"~a & ~b", "((~a) & (~b))"},
+    {"a || b && c", "(a || (b && c))"},
+    {"a && b || c && d", "((a && b) || (c && d))"},
+    {"a == b && c < d", "((a == b) && (c < d))"},
+    {"x = a || b", "(x = (a || b))"},
+    {"a = b = c", "(a = (b = c))"},
+    {"a += b * c == d", "(a += ((b * c) == d))"},
+    {"a -= 1; b *= 2; c /= 3", "(a -= 1)(b *= 2)(c /= 3)"},
   }
 
   for _, tt := range tests {
@@ -452,6 +570,115 @@ func TestIfExpression(t *testing.T) {
   }
 }
 
+func TestAssignmentTarget(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {"1 = 2", "1:3: Cannot assign to 1"},
+    {"a + b = c", "1:7: Cannot assign to (a + b)"},
+    {"f() += 1", "1:5: Cannot assign to f()"},
+  }
+
+  for _, tt := range tests {
+    parser := NewParser(NewLexer(tt.input))
+    parser.Parse()
+
+    errors := parser.Errors()
+
+    if len(errors) != 1 || errors[0] != tt.expected {
+      t.Errorf("%q: wrong errors. want=%q, got=%q",
+        tt.input, tt.expected, errors)
+    }
+  }
+}
+
+func TestWhileStatement(t *testing.T) {
+  program := setup(t, "while (x < y) { x; break; continue; }")
+
+  if len(program.Statements) != 1 {
+    t.Fatalf("program has wrong number of statements. got=%d",
+      len(program.Statements))
+  }
+
+  statement, ok := program.Statements[0].(*WhileStatement)
+  if !ok {
+    t.Fatalf("statement is not *WhileStatement. got=%T",
+      program.Statements[0])
+  }
+
+  testInfixExpression(t, statement.Condition, "x", "<", "y")
+
+  if len(statement.Body.Statements) != 3 {
+    t.Fatalf("body has wrong number of statements. got=%d",
+      len(statement.Body.Statements))
+  }
+
+  if _, ok := statement.Body.Statements[1].(*BreakStatement); !ok {
+    t.Errorf("statement is not *BreakStatement. got=%T",
+      statement.Body.Statements[1])
+  }
+
+  if _, ok := statement.Body.Statements[2].(*ContinueStatement); !ok {
+    t.Errorf("statement is not *ContinueStatement. got=%T",
+      statement.Body.Statements[2])
+  }
+}
+
+func TestForStatement(t *testing.T) {
+  program := setup(t, "for (x in [1, 2]) { puts(x) }; 5")
+
+  if len(program.Statements) != 2 {
+    t.Fatalf("program has wrong number of statements. got=%d",
+      len(program.Statements))
+  }
+
+  statement, ok := program.Statements[0].(*ForStatement)
+  if !ok {
+    t.Fatalf("statement is not *ForStatement. got=%T",
+      program.Statements[0])
+  }
+
+  testIdentifier(t, statement.Variable, "x")
+
+  expected := "for (x in [1, 2]) puts(x)"
+
+  if statement.String() != expected {
+    t.Errorf("statement.String() wrong. want=%q, got=%q",
+      expected, statement.String())
+  }
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {"break;", "1:1: Unexpected break outside of a loop"},
+    {"if (true) { continue }", "1:13: Unexpected continue outside of a loop"},
+    {
+      "while (true) { fn() { break; } }",
+      "1:23: Unexpected break outside of a loop",
+    },
+    {
+      "while (true) { fn(x = if (true) { break; } else { 1 }) { x } }",
+      "1:35: Unexpected break outside of a loop",
+    },
+  }
+
+  for _, tt := range tests {
+    parser := NewParser(NewLexer(tt.input))
+    parser.Parse()
+
+    errors := parser.Errors()
+
+    if len(errors) != 1 || errors[0] != tt.expected {
+      t.Errorf("%q: wrong errors. want=%q, got=%q",
+        tt.input, tt.expected, errors)
+    }
+  }
+}
+
 func TestFunctionLiteral(t *testing.T) {
   program := setup(t, `fn(x, y) { x + y; }`)
 
@@ -541,6 +768,146 @@ func TestFunctionParameter(t *testing.T) {
   }
 }
 
+func TestFunctionParameterDefaults(t *testing.T) {
+  program := setup(t, "fn(x, y = 10, z = x + 1) { x };")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  function := stmt.Expression.(*FunctionLiteral)
+
+  if len(function.Parameters) != 3 {
+    t.Fatalf("wrong number of parameters. got=%d", len(function.Parameters))
+  }
+
+  if _, ok := function.Defaults["x"]; ok {
+    t.Errorf("required parameter x has a default value")
+  }
+
+  testLiteralExpression(t, function.Defaults["y"], 10)
+  testInfixExpression(t, function.Defaults["z"], "x", "+", 1)
+
+  expected := "fn(x, y = 10, z = (x + 1)) x"
+
+  if function.String() != expected {
+    t.Errorf("function.String() wrong. want=%q, got=%q",
+      expected, function.String())
+  }
+
+  parser := NewParser(NewLexer("fn(x = 1, y) { x };"))
+  parser.Parse()
+
+  errors := parser.Errors()
+
+  if len(errors) != 1 {
+    t.Fatalf("wrong number of errors. want=1, got=%d (%q)",
+      len(errors), errors)
+  }
+
+  message := "1:11: Required parameter y follows a parameter with a " +
+    "default value"
+
+  if errors[0] != message {
+    t.Errorf("wrong error. want=%q, got=%q", message, errors[0])
+  }
+}
+
+func TestRestParameter(t *testing.T) {
+  tests := []struct {
+    input          string
+    expectedParams []string
+    expectedRest   string
+    expected       string
+  }{
+    {"fn(...rest) {};", []string{}, "rest", "fn(...rest) "},
+    {
+      "fn(x, y = 1, ...rest) {};",
+      []string{"x", "y"},
+      "rest",
+      "fn(x, y = 1, ...rest) ",
+    },
+  }
+
+  for _, tt := range tests {
+    program := setup(t, tt.input)
+
+    stmt := program.Statements[0].(*ExpressionStatement)
+
+    function := stmt.Expression.(*FunctionLiteral)
+
+    if len(function.Parameters) != len(tt.expectedParams) {
+      t.Fatalf("wrong number of parameters. want=%d, got=%d",
+        len(tt.expectedParams), len(function.Parameters))
+    }
+
+    for i, ident := range tt.expectedParams {
+      testLiteralExpression(t, function.Parameters[i], ident)
+    }
+
+    testLiteralExpression(t, function.Rest, tt.expectedRest)
+
+    if function.String() != tt.expected {
+      t.Errorf("function.String() wrong. want=%q, got=%q",
+        tt.expected, function.String())
+    }
+  }
+
+  errorTests := []struct {
+    input    string
+    expected string
+  }{
+    {"fn(...rest, x) {};", "1:11: Rest parameter rest must be last"},
+    {"[...xs];", "1:2: No prefix parse function for ... found"},
+    {
+      "fn(...1) { 1 };",
+      "1:7: Expected next token to be IDENT but got INT instead",
+    },
+    {
+      "fn(x, ...) { x };",
+      "1:10: Expected next token to be IDENT but got ) instead",
+    },
+  }
+
+  for _, tt := range errorTests {
+    parser := NewParser(NewLexer(tt.input))
+    parser.Parse()
+
+    errors := parser.Errors()
+
+    if len(errors) != 1 || errors[0] != tt.expected {
+      t.Errorf("%q: wrong errors. want=%q, got=%q",
+        tt.input, tt.expected, errors)
+    }
+  }
+}
+
+func TestSpreadArguments(t *testing.T) {
+  program := setup(t, "f(1, ...xs, ...[2, 3]);")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  call, ok := stmt.Expression.(*CallExpression)
+  if !ok {
+    t.Fatalf("expression is not *CallExpression. got=%T", stmt.Expression)
+  }
+
+  if len(call.Arguments) != 3 {
+    t.Fatalf("wrong number of arguments. got=%d", len(call.Arguments))
+  }
+
+  spread, ok := call.Arguments[1].(*SpreadExpression)
+  if !ok {
+    t.Fatalf("argument is not *SpreadExpression. got=%T", call.Arguments[1])
+  }
+
+  testIdentifier(t, spread.Value, "xs")
+
+  expected := "f(1, ...xs, ...[2, 3])"
+
+  if call.String() != expected {
+    t.Errorf("call.String() wrong. want=%q, got=%q", expected, call.String())
+  }
+}
+
 func TestCallExpression(t *testing.T) {
   program := setup(t, "add(1, 2 * 3, 4 + 5);")
 
@@ -586,6 +953,361 @@ func TestCallExpression(t *testing.T) {
   testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
 
+func TestArrayLiteral(t *testing.T) {
+  program := setup(t, "[1, 2 * 2, 3 + 3]")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  array, ok := stmt.Expression.(*ArrayLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not an *ArrayLiteral. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  if len(array.Elements) != 3 {
+    t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+  }
+
+  testIntegerLiteral(t, array.Elements[0], 1)
+  testInfixExpression(t, array.Elements[1], 2, "*", 2)
+  testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestEmptyArrayLiteral(t *testing.T) {
+  program := setup(t, "[]")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  array, ok := stmt.Expression.(*ArrayLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not an *ArrayLiteral. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  if len(array.Elements) != 0 {
+    t.Fatalf("len(array.Elements) not 0. got=%d", len(array.Elements))
+  }
+}
+
+func TestIndexExpression(t *testing.T) {
+  program := setup(t, "myArray[1 + 1]")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  index, ok := stmt.Expression.(*IndexExpression)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not an *IndexExpression. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  if !testIdentifier(t, index.Left, "myArray") {
+    return
+  }
+
+  testInfixExpression(t, index.Index, 1, "+", 1)
+}
+
+func TestHashLiteralStringKeys(t *testing.T) {
+  program := setup(t, `{"one": 1, "two": 2, "three": 3}`)
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  hash, ok := stmt.Expression.(*HashLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not a *HashLiteral. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  expected := []struct {
+    key   string
+    value int64
+  }{
+    {"one", 1},
+    {"two", 2},
+    {"three", 3},
+  }
+
+  if len(hash.Keys) != len(expected) {
+    t.Fatalf("hash.Keys has wrong length. got=%d", len(hash.Keys))
+  }
+
+  for i, tt := range expected {
+    literal, ok := hash.Keys[i].(*StringLiteral)
+
+    if !ok {
+      t.Errorf("key is not *StringLiteral. got=%T", hash.Keys[i])
+      continue
+    }
+
+    if literal.Value != tt.key {
+      t.Errorf("key not %q. got=%q", tt.key, literal.Value)
+    }
+
+    testIntegerLiteral(t, hash.Values[i], tt.value)
+  }
+}
+
+func TestEmptyHashLiteral(t *testing.T) {
+  program := setup(t, "{}")
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  hash, ok := stmt.Expression.(*HashLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not a *HashLiteral. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  if len(hash.Keys) != 0 {
+    t.Errorf("hash.Keys has wrong length. got=%d", len(hash.Keys))
+  }
+}
+
+func TestHashLiteralWithExpressions(t *testing.T) {
+  program := setup(t, `{"one": 0 + 1, true: 10 - 8, 3: 15 / 5}`)
+
+  stmt := program.Statements[0].(*ExpressionStatement)
+
+  hash, ok := stmt.Expression.(*HashLiteral)
+
+  if !ok {
+    t.Fatalf(
+      "stmt.Expression is not a *HashLiteral. got=%T",
+      stmt.Expression,
+    )
+  }
+
+  if len(hash.Values) != 3 {
+    t.Fatalf("hash.Values has wrong length. got=%d", len(hash.Values))
+  }
+
+  testLiteralExpression(t, hash.Keys[1], true)
+  testLiteralExpression(t, hash.Keys[2], 3)
+
+  testInfixExpression(t, hash.Values[0], 0, "+", 1)
+  testInfixExpression(t, hash.Values[1], 10, "-", 8)
+  testInfixExpression(t, hash.Values[2], 15, "/", 5)
+
+  if hash.String() != `{"one": (0 + 1), true: (10 - 8), 3: (15 / 5)}` {
+    t.Errorf("hash.String() wrong. got=%q", hash.String())
+  }
+}
+
+func TestHashLiteralErrors(t *testing.T) {
+  tests := []string{
+    `{"one" 1}`,
+    `{"one": 1 "two": 2}`,
+    `{"one": 1`,
+  }
+
+  for _, input := range tests {
+    parser := NewParser(NewLexer(input))
+    parser.Parse()
+
+    if len(parser.Errors()) == 0 {
+      t.Errorf("expected parser errors for %q", input)
+    }
+  }
+}
+
+func TestParserErrorPositions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {
+      "let x 5;",
+      "1:7: Expected next token to be = but got INT instead",
+    },
+    {
+      "let x = 1;\nadd(1, 2;",
+      "2:9: Expected next token to be ) but got ; instead",
+    },
+    {
+      "\n\n  ;",
+      "3:3: No prefix parse function for ; found",
+    },
+    {
+      "09",
+      "1:2: invalid digit '9' in octal literal",
+    },
+    {
+      "let x = 0xZZ;\nlet y = 1 +;",
+      "1:11: invalid digit 'Z' in hexadecimal literal",
+    },
+    {
+      "let s = \"a\\qb\";",
+      "1:11: invalid escape \\q",
+    },
+    {
+      "let s = \"abc",
+      "1:9: unterminated string",
+    },
+  }
+
+  for _, tt := range tests {
+    parser := NewParser(NewLexer(tt.input))
+    parser.Parse()
+
+    errors := parser.Errors()
+
+    if len(errors) == 0 {
+      t.Errorf("expected parser errors for %q", tt.input)
+      continue
+    }
+
+    if errors[0] != tt.expected {
+      t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+    }
+  }
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+  tests := []struct {
+    input      string
+    expected   []string
+    statements []string
+  }{
+    {
+      "let x = (1;\nlet = 2;\nlet y = 3;",
+      []string{
+        "1:11: Expected next token to be ) but got ; instead",
+        "2:5: Expected next token to be IDENT but got = instead",
+      },
+      []string{"let y = 3;"},
+    },
+    {
+      "let f = fn(a {\n  a + 1\n};\nf(1)",
+      []string{"1:14: Expected next token to be ) but got { instead"},
+      []string{"f(1)"},
+    },
+    {
+      "let g = fn(a) {\n  let b = ;\n  a\n};\ng",
+      []string{"2:11: No prefix parse function for ; found"},
+      []string{"let g = fn(a) a;", "g"},
+    },
+    {
+      "if (x { 1 } else { 2 };\nadd(1, 2;\nreturn 3",
+      []string{
+        "1:7: Expected next token to be ) but got { instead",
+        "2:9: Expected next token to be ) but got ; instead",
+      },
+      []string{"return 3;"},
+    },
+    {
+      "let a = 1 +\nlet b = * 2\nlet c = 3",
+      []string{
+        "2:1: No prefix parse function for LET found",
+        "2:9: No prefix parse function for * found",
+      },
+      []string{"let c = 3;"},
+    },
+    {
+      "let f = fn() {\n  1 +\n};\nf()",
+      []string{"3:1: No prefix parse function for } found"},
+      []string{"let f = fn() ;", "f()"},
+    },
+    {
+      "}\nlet a = 1;",
+      []string{"1:1: No prefix parse function for } found"},
+      []string{"let a = 1;"},
+    },
+    {
+      "let k = {1: };\nlet a = 1;",
+      []string{"1:13: No prefix parse function for } found"},
+      []string{"let a = 1;"},
+    },
+    {
+      "let k = {1: , 2: 3};\nlet a = 1;",
+      []string{"1:13: No prefix parse function for , found"},
+      []string{"let a = 1;"},
+    },
+    {
+      "let f = fn() {\n  let k = {1: };\n  2\n};\nf()",
+      []string{"2:15: No prefix parse function for } found"},
+      []string{"let f = fn() 2;", "f()"},
+    },
+  }
+
+  for _, tt := range tests {
+    parser := NewParser(NewLexer(tt.input))
+    program := parser.Parse()
+
+    errors := parser.Errors()
+
+    if len(errors) != len(tt.expected) {
+      t.Errorf("%q: wrong number of errors. want=%d, got=%d (%q)",
+        tt.input, len(tt.expected), len(errors), errors)
+      continue
+    }
+
+    for i, expected := range tt.expected {
+      if errors[i] != expected {
+        t.Errorf("wrong error. expected=%q, got=%q", expected, errors[i])
+      }
+    }
+
+    if len(program.Statements) != len(tt.statements) {
+      t.Errorf("%q: wrong number of statements. want=%d, got=%d",
+        tt.input, len(tt.statements), len(program.Statements))
+      continue
+    }
+
+    for i, expected := range tt.statements {
+      if program.Statements[i].String() != expected {
+        t.Errorf("wrong statement. expected=%q, got=%q",
+          expected, program.Statements[i].String())
+      }
+    }
+  }
+}
+
+func TestNodePositions(t *testing.T) {
+  program := setup(t, "let a = 1;\nfn(x) {\n  x * [a][0]\n}")
+
+  function := program.Statements[1].(*ExpressionStatement).
+    Expression.(*FunctionLiteral)
+
+  body := function.Body.Statements[0].(*ExpressionStatement)
+  infix := body.Expression.(*InfixExpression)
+  index := infix.Right.(*IndexExpression)
+
+  tests := []struct {
+    node     Node
+    expected Position
+  }{
+    {&program, Position{Line: 1, Column: 1, Offset: 0}},
+    {function, Position{Line: 2, Column: 1, Offset: 11}},
+    {function.Body, Position{Line: 2, Column: 7, Offset: 17}},
+    {infix.Left, Position{Line: 3, Column: 3, Offset: 21}},
+    {infix, Position{Line: 3, Column: 5, Offset: 23}},
+    {index.Left, Position{Line: 3, Column: 7, Offset: 25}},
+    {index, Position{Line: 3, Column: 10, Offset: 28}},
+  }
+
+  for _, tt := range tests {
+    if tt.node.Pos() != tt.expected {
+      t.Errorf("%s: wrong position. expected=%+v, got=%+v",
+        tt.node, tt.expected, tt.node.Pos())
+    }
+  }
+}
+
 func validate(t *testing.T, p *Parser) {
   errors := p.Errors()
 
@@ -4,14 +4,24 @@ import (
   "bufio"
   "fmt"
   "io"
+  "os"
   "strings"
 )
 
 const PROMPT = ">> "
 
-func Repl(in io.Reader, out io.Writer) {
+func Repl(in io.Reader, out io.Writer, engine Engine) {
   scanner := bufio.NewScanner(in)
-  env := NewEnvironment()
+
+  color := false
+
+  if f, ok := out.(*os.File); ok {
+    color = isTerminal(f)
+  }
+
+  // Every line entered so far, so that diagnostics raised by functions
+  // defined on earlier lines can still show their source.
+  history := []string{}
 
   for {
     fmt.Print(PROMPT)
@@ -35,18 +45,28 @@ func Repl(in io.Reader, out io.Writer) {
       continue
     }
 
-    parser := NewParser(NewLexer(line))
+    history = append(history, line)
+
+    renderer := NewRenderer("<repl>", strings.Join(history, "\n"), color)
+
+    lexer := NewLexer(line)
+    lexer.line = len(history)
+
+    parser := NewParser(lexer)
 
     program := parser.Parse()
 
-    if len(parser.Errors()) != 0 {
-      for _, message := range parser.Errors() {
-        fmt.Println(message)
-      }
+    if diagnostics := parser.Diagnostics(); len(diagnostics) != 0 {
+      renderer.Render(out, diagnostics...)
       continue
     }
 
-    evaluated := Eval(program, env)
+    evaluated := engine.Run(program)
+
+    if err, ok := evaluated.(*Error); ok {
+      renderer.Render(out, err.Diagnostic())
+      continue
+    }
 
     if evaluated != nil {
       io.WriteString(out, evaluated.Inspect())
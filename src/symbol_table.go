@@ -0,0 +1,203 @@
+package main
+
+type SymbolScope string
+
+const (
+  FREE_SCOPE     SymbolScope = "FREE"
+  FUNCTION_SCOPE SymbolScope = "FUNCTION"
+  GLOBAL_SCOPE   SymbolScope = "GLOBAL"
+  LOCAL_SCOPE    SymbolScope = "LOCAL"
+)
+
+type Symbol struct {
+  Name     string
+  Scope    SymbolScope
+  Index    int
+  Constant bool
+}
+
+type SymbolTable struct {
+  Outer          *SymbolTable
+  FreeSymbols    []Symbol
+  declared       map[string]bool
+  numDefinitions int
+  reserved       map[string]Symbol
+  store          map[string]Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+  return &SymbolTable{
+    FreeSymbols: []Symbol{},
+    declared:    make(map[string]bool),
+    reserved:    make(map[string]Symbol),
+    store:       make(map[string]Symbol),
+  }
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+  s := NewSymbolTable()
+  s.Outer = outer
+  return s
+}
+
+// Define binds name in the current scope. Redefining a name that already
+// lives in this scope reuses its slot, mirroring how `Environment.Set`
+// overwrites an existing binding.
+func (s *SymbolTable) Define(name string) Symbol {
+  if symbol, ok := s.store[name]; ok {
+    if symbol.Scope == GLOBAL_SCOPE || symbol.Scope == LOCAL_SCOPE {
+      return symbol
+    }
+  }
+
+  if symbol, ok := s.reserved[name]; ok {
+    delete(s.reserved, name)
+    s.store[name] = symbol
+    return symbol
+  }
+
+  symbol := Symbol{Name: name, Index: s.numDefinitions}
+
+  if s.Outer == nil {
+    symbol.Scope = GLOBAL_SCOPE
+  } else {
+    symbol.Scope = LOCAL_SCOPE
+  }
+
+  s.store[name] = symbol
+  s.numDefinitions++
+
+  return symbol
+}
+
+// Names returns the names of the variables defined in this scope, indexed
+// by their slots, including the slots reserved for names not defined yet.
+func (s *SymbolTable) Names() []string {
+  names := make([]string, s.numDefinitions)
+
+  for _, symbols := range []map[string]Symbol{s.store, s.reserved} {
+    for name, symbol := range symbols {
+      if symbol.Scope == GLOBAL_SCOPE || symbol.Scope == LOCAL_SCOPE {
+        names[symbol.Index] = name
+      }
+    }
+  }
+
+  return names
+}
+
+// Predeclare records the names that will be defined in this scope, which
+// lets Reserve find the scope of a name used before its definition.
+func (s *SymbolTable) Predeclare(names map[string]bool) {
+  s.declared = names
+}
+
+// Reserve returns the slot that name will occupy once it is defined, so
+// that code compiled before the definition, like a function calling one
+// declared after it, can look the variable up at run time. The slot is in
+// the innermost scope that predeclares name, or is a global otherwise.
+func (s *SymbolTable) Reserve(name string) Symbol {
+  if symbol, ok := s.reserved[name]; ok {
+    return symbol
+  }
+
+  if s.Outer != nil && !s.declared[name] {
+    symbol := s.Outer.Reserve(name)
+
+    if symbol.Scope == GLOBAL_SCOPE {
+      return symbol
+    }
+
+    return s.defineFree(symbol)
+  }
+
+  symbol := Symbol{Name: name, Index: s.numDefinitions}
+
+  if s.Outer == nil {
+    symbol.Scope = GLOBAL_SCOPE
+  } else {
+    symbol.Scope = LOCAL_SCOPE
+  }
+
+  s.reserved[name] = symbol
+  s.numDefinitions++
+
+  return symbol
+}
+
+// DefineConstant binds name in the current scope as a constant.
+func (s *SymbolTable) DefineConstant(name string) Symbol {
+  symbol := s.Define(name)
+  symbol.Constant = true
+  s.store[name] = symbol
+  return symbol
+}
+
+// Constant reports whether name is bound as a constant in the current
+// scope, which prevents it from being bound again there.
+func (s *SymbolTable) Constant(name string) bool {
+  symbol, ok := s.store[name]
+
+  if !ok || symbol.Scope == FREE_SCOPE {
+    return false
+  }
+
+  return symbol.Constant
+}
+
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+  symbol := Symbol{Name: name, Index: 0, Scope: FUNCTION_SCOPE}
+  s.store[name] = symbol
+  return symbol
+}
+
+// Hide removes the named symbols from this scope, so that the names
+// resolve as they would in the enclosing scope, until the returned function
+// restores them.
+func (s *SymbolTable) Hide(names ...string) func() {
+  hidden := []Symbol{}
+
+  for _, name := range names {
+    if symbol, ok := s.store[name]; ok {
+      hidden = append(hidden, symbol)
+      delete(s.store, name)
+    }
+  }
+
+  return func() {
+    for _, symbol := range hidden {
+      s.store[symbol.Name] = symbol
+    }
+  }
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+  symbol, ok := s.store[name]
+
+  if ok || s.Outer == nil {
+    return symbol, ok
+  }
+
+  symbol, ok = s.Outer.Resolve(name)
+
+  if !ok || symbol.Scope == GLOBAL_SCOPE {
+    return symbol, ok
+  }
+
+  return s.defineFree(symbol), true
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+  s.FreeSymbols = append(s.FreeSymbols, original)
+
+  symbol := Symbol{
+    Name:     original.Name,
+    Index:    len(s.FreeSymbols) - 1,
+    Scope:    FREE_SCOPE,
+    Constant: original.Constant,
+  }
+
+  s.store[original.Name] = symbol
+
+  return symbol
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+  "reflect"
+  "testing"
+)
+
+func TestDefine(t *testing.T) {
+  global := NewSymbolTable()
+
+  a := global.Define("a")
+  b := global.Define("b")
+
+  if a != (Symbol{Name: "a", Scope: GLOBAL_SCOPE, Index: 0}) {
+    t.Errorf("a wrong. got=%+v", a)
+  }
+
+  if b != (Symbol{Name: "b", Scope: GLOBAL_SCOPE, Index: 1}) {
+    t.Errorf("b wrong. got=%+v", b)
+  }
+
+  if again := global.Define("a"); again != a {
+    t.Errorf("redefining a should reuse its slot. got=%+v", again)
+  }
+
+  local := NewEnclosedSymbolTable(global)
+
+  c := local.Define("c")
+  shadow := local.Define("a")
+
+  if c != (Symbol{Name: "c", Scope: LOCAL_SCOPE, Index: 0}) {
+    t.Errorf("c wrong. got=%+v", c)
+  }
+
+  if shadow != (Symbol{Name: "a", Scope: LOCAL_SCOPE, Index: 1}) {
+    t.Errorf("shadowing a wrong. got=%+v", shadow)
+  }
+}
+
+func TestResolveFree(t *testing.T) {
+  global := NewSymbolTable()
+  global.Define("a")
+
+  first := NewEnclosedSymbolTable(global)
+  first.Define("b")
+
+  second := NewEnclosedSymbolTable(first)
+  second.Define("c")
+
+  expected := []Symbol{
+    {Name: "a", Scope: GLOBAL_SCOPE, Index: 0},
+    {Name: "b", Scope: FREE_SCOPE, Index: 0},
+    {Name: "c", Scope: LOCAL_SCOPE, Index: 0},
+  }
+
+  for _, symbol := range expected {
+    result, ok := second.Resolve(symbol.Name)
+
+    if !ok {
+      t.Errorf("name %s not resolvable", symbol.Name)
+      continue
+    }
+
+    if result != symbol {
+      t.Errorf("expected %s to resolve to %+v, got=%+v",
+        symbol.Name, symbol, result)
+    }
+  }
+
+  if len(second.FreeSymbols) != 1 {
+    t.Fatalf("wrong number of free symbols. got=%d", len(second.FreeSymbols))
+  }
+
+  if second.FreeSymbols[0] != (Symbol{Name: "b", Scope: LOCAL_SCOPE}) {
+    t.Errorf("wrong free symbol. got=%+v", second.FreeSymbols[0])
+  }
+
+  if _, ok := second.Resolve("d"); ok {
+    t.Errorf("name d resolved, but was never defined")
+  }
+}
+
+func TestDefineConstant(t *testing.T) {
+  global := NewSymbolTable()
+  global.DefineConstant("a")
+  global.Define("b")
+
+  if !global.Constant("a") || global.Constant("b") {
+    t.Errorf("only a should be constant")
+  }
+
+  local := NewEnclosedSymbolTable(global)
+
+  expected := Symbol{Name: "a", Scope: GLOBAL_SCOPE, Index: 0, Constant: true}
+
+  if result, ok := local.Resolve("a"); !ok || result != expected {
+    t.Errorf("expected a to resolve to %+v, got=%+v", expected, result)
+  }
+
+  if local.Constant("a") {
+    t.Errorf("a should not be constant in an enclosed scope")
+  }
+}
+
+func TestDefineFunctionName(t *testing.T) {
+  global := NewSymbolTable()
+  global.DefineFunctionName("a")
+
+  expected := Symbol{Name: "a", Scope: FUNCTION_SCOPE, Index: 0}
+
+  if result, ok := global.Resolve("a"); !ok || result != expected {
+    t.Errorf("expected a to resolve to %+v, got=%+v", expected, result)
+  }
+}
+
+func TestHide(t *testing.T) {
+  global := NewSymbolTable()
+  global.Define("a")
+
+  local := NewEnclosedSymbolTable(global)
+  local.Define("a")
+  local.Define("b")
+
+  restore := local.Hide("a", "b")
+
+  expected := Symbol{Name: "a", Scope: GLOBAL_SCOPE, Index: 0}
+
+  if result, ok := local.Resolve("a"); !ok || result != expected {
+    t.Errorf("expected a to resolve to %+v, got=%+v", expected, result)
+  }
+
+  if _, ok := local.Resolve("b"); ok {
+    t.Errorf("hidden name b resolved")
+  }
+
+  restore()
+
+  expected = Symbol{Name: "b", Scope: LOCAL_SCOPE, Index: 1}
+
+  if result, ok := local.Resolve("b"); !ok || result != expected {
+    t.Errorf("expected b to resolve to %+v, got=%+v", expected, result)
+  }
+}
+
+func TestReserve(t *testing.T) {
+  global := NewSymbolTable()
+  global.Define("a")
+
+  local := NewEnclosedSymbolTable(global)
+
+  expected := Symbol{Name: "b", Scope: GLOBAL_SCOPE, Index: 1}
+
+  if result := local.Reserve("b"); result != expected {
+    t.Errorf("expected b to reserve %+v, got=%+v", expected, result)
+  }
+
+  if _, ok := local.Resolve("b"); ok {
+    t.Errorf("reserved name b resolved before its definition")
+  }
+
+  global.Define("c")
+
+  if result := global.Define("b"); result != expected {
+    t.Errorf("expected b to be defined as %+v, got=%+v", expected, result)
+  }
+
+  if result, ok := local.Resolve("b"); !ok || result != expected {
+    t.Errorf("expected b to resolve to %+v, got=%+v", expected, result)
+  }
+}
+
+func TestNames(t *testing.T) {
+  global := NewSymbolTable()
+  global.Define("a")
+  global.Reserve("b")
+  global.Define("c")
+
+  expected := []string{"a", "b", "c"}
+
+  if names := global.Names(); !reflect.DeepEqual(names, expected) {
+    t.Errorf("wrong names. want=%q, got=%q", expected, names)
+  }
+}
+
+func TestReservePredeclared(t *testing.T) {
+  global := NewSymbolTable()
+
+  outer := NewEnclosedSymbolTable(global)
+  outer.Predeclare(map[string]bool{"a": true})
+
+  inner := NewEnclosedSymbolTable(outer)
+
+  expected := Symbol{Name: "a", Scope: FREE_SCOPE, Index: 0}
+
+  if result := inner.Reserve("a"); result != expected {
+    t.Errorf("expected a to reserve %+v, got=%+v", expected, result)
+  }
+
+  expected = Symbol{Name: "a", Scope: LOCAL_SCOPE, Index: 0}
+
+  if result := outer.Define("a"); result != expected {
+    t.Errorf("expected a to be defined as %+v, got=%+v", expected, result)
+  }
+
+  expected = Symbol{Name: "b", Scope: GLOBAL_SCOPE, Index: 0}
+
+  if result := inner.Reserve("b"); result != expected {
+    t.Errorf("expected b to reserve %+v, got=%+v", expected, result)
+  }
+}
@@ -1,13 +1,23 @@
 package main
 
+import (
+  "fmt"
+)
+
 var keywords = map[string]TokenKind{
-  "else":   ELSE,
-  "false":  FALSE,
-  "fn":     FUNCTION,
-  "if":     IF,
-  "let":    LET,
-  "return": RETURN,
-  "true":   TRUE,
+  "break":    BREAK,
+  "const":    CONST,
+  "continue": CONTINUE,
+  "else":     ELSE,
+  "false":    FALSE,
+  "fn":       FUNCTION,
+  "for":      FOR,
+  "if":       IF,
+  "in":       IN,
+  "let":      LET,
+  "return":   RETURN,
+  "true":     TRUE,
+  "while":    WHILE,
 }
 
 func LookupIdent(ident string) TokenKind {
@@ -19,42 +29,96 @@ func LookupIdent(ident string) TokenKind {
 }
 
 const (
-  ASSIGN    = "="
-  ASTERISK  = "*"
-  BANG      = "!"
-  COMMA     = ","
-  ELSE      = "ELSE"
-  EOF       = "EOF"
-  EQ        = "=="
-  FALSE     = "FALSE"
-  FUNCTION  = "FUNCTION"
-  GT        = ">"
-  IDENT     = "IDENT"
-  IF        = "IF"
-  ILLEGAL   = "ILLEGAL"
-  INT       = "INT"
-  LBRACE    = "{"
-  LET       = "LET"
-  LPAREN    = "("
-  LT        = "<"
-  MINUS     = "-"
-  NOT_EQ    = "!="
-  PLUS      = "+"
-  RBRACE    = "}"
-  RETURN    = "RETURN"
-  RPAREN    = ")"
-  SEMICOLON = ";"
-  SLASH     = "/"
-  TRUE      = "TRUE"
+  AMPERSAND       = "&"
+  AND             = "&&"
+  ASSIGN          = "="
+  ASTERISK        = "*"
+  ASTERISK_ASSIGN = "*="
+  BANG            = "!"
+  BREAK           = "BREAK"
+  CARET           = "^"
+  COLON           = ":"
+  COMMA           = ","
+  CONST           = "CONST"
+  CONTINUE        = "CONTINUE"
+  ELLIPSIS        = "..."
+  ELSE            = "ELSE"
+  EOF             = "EOF"
+  EQ              = "=="
+  FALSE           = "FALSE"
+  FLOAT           = "FLOAT"
+  FOR             = "FOR"
+  FUNCTION        = "FUNCTION"
+  GT              = ">"
+  GT_EQ           = ">="
+  IDENT           = "IDENT"
+  IF              = "IF"
+  ILLEGAL         = "ILLEGAL"
+  IN              = "IN"
+  INT             = "INT"
+  LBRACE          = "{"
+  LBRACKET        = "["
+  LET             = "LET"
+  LPAREN          = "("
+  LT              = "<"
+  LT_EQ           = "<="
+  MINUS           = "-"
+  MINUS_ASSIGN    = "-="
+  NOT_EQ          = "!="
+  OR              = "||"
+  PIPE            = "|"
+  PERCENT         = "%"
+  PLUS            = "+"
+  PLUS_ASSIGN     = "+="
+  POWER           = "**"
+  RBRACE          = "}"
+  RBRACKET        = "]"
+  RETURN          = "RETURN"
+  RPAREN          = ")"
+  SEMICOLON       = ";"
+  SHIFT_LEFT      = "<<"
+  SHIFT_RIGHT     = ">>"
+  SLASH           = "/"
+  SLASH_ASSIGN    = "/="
+  STRING          = "STRING"
+  TILDE           = "~"
+  TRUE            = "TRUE"
+  WHILE           = "WHILE"
 )
 
 type TokenKind string
 
+// Position is a location in source text. Lines and columns start at 1, and
+// columns count characters rather than bytes, while the offset is the
+// number of bytes preceding the location.
+type Position struct {
+  Line   int
+  Column int
+  Offset int
+}
+
+func (p Position) IsValid() bool {
+  return p.Line > 0
+}
+
+func (p Position) String() string {
+  return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 type Token struct {
-  Kind    TokenKind
-  Literal string
+  Kind     TokenKind
+  Literal  string
+  Position Position
+  End      Position
+}
+
+// Comment is a comment skipped by the lexer, kept so that tools such as a
+// formatter can put it back in place.
+type Comment struct {
+  Text string
+  Span Span
 }
 
-func NewToken(kind TokenKind, ch byte) Token {
+func NewToken(kind TokenKind, ch rune) Token {
   return Token{Kind: kind, Literal: string(ch)}
 }
@@ -1,13 +1,45 @@
 package main
 
-func isDigit(ch byte) bool {
+import (
+  "unicode"
+  "unicode/utf8"
+)
+
+func isDigit(ch rune) bool {
   return '0' <= ch && ch <= '9'
 }
 
-func isLetter(ch byte) bool {
-  return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isHexDigit(ch rune) bool {
+  return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// digitValue returns the value of a hexadecimal digit, or 16 if ch is not
+// one.
+func digitValue(ch rune) int {
+  switch {
+  case isDigit(ch):
+    return int(ch - '0')
+  case 'a' <= ch && ch <= 'f':
+    return int(ch-'a') + 10
+  case 'A' <= ch && ch <= 'F':
+    return int(ch-'A') + 10
+  default:
+    return 16
+  }
+}
+
+// isNumberPart reports whether ch may continue a numeric literal. ASCII
+// letters are included so that malformed literals are consumed whole.
+func isNumberPart(ch rune) bool {
+  return isDigit(ch) || ch < utf8.RuneSelf && isLetter(ch)
+}
+
+// isLetter reports whether ch may appear in an identifier, which allows
+// letters from any script.
+func isLetter(ch rune) bool {
+  return unicode.IsLetter(ch) || ch == '_'
 }
 
-func isWhitespace(ch byte) bool {
+func isWhitespace(ch rune) bool {
   return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
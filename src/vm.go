@@ -0,0 +1,639 @@
+package main
+
+import (
+  "fmt"
+)
+
+const (
+  GLOBALS_SIZE = 65536
+  STACK_SIZE   = 2048
+)
+
+type Frame struct {
+  arguments   int
+  basePointer int
+  closure     *Closure
+  ip          int
+}
+
+func NewFrame(closure *Closure, basePointer int) *Frame {
+  return &Frame{basePointer: basePointer, closure: closure, ip: -1}
+}
+
+func (f *Frame) Instructions() Instructions {
+  return f.closure.Fn.Instructions
+}
+
+// VM executes bytecode. Its stack starts out STACK_SIZE slots large and
+// grows as calls nest, so the depth of recursion is bounded by maxDepth
+// rather than by the size of the stack.
+type VM struct {
+  arithmetic  Arithmetic
+  constants   []Object
+  frames      []*Frame
+  framesIndex int
+  globalNames []string
+  globals     []Object
+  maxDepth    int
+  result      Object
+  sp          int
+  stack       []Object
+}
+
+func NewVM(bytecode *Bytecode) *VM {
+  return NewVMWithGlobals(bytecode, make([]Object, GLOBALS_SIZE))
+}
+
+// NewVMWithGlobals creates a virtual machine that shares a globals store
+// with previous runs, which lets the REPL keep globals alive between lines.
+func NewVMWithGlobals(bytecode *Bytecode, globals []Object) *VM {
+  main := &CompiledFunction{
+    Instructions: bytecode.Instructions,
+    SourceMap:    bytecode.SourceMap,
+  }
+
+  return &VM{
+    arithmetic:  ARITHMETIC_BIG,
+    constants:   bytecode.Constants,
+    frames:      []*Frame{NewFrame(&Closure{Fn: main}, 0)},
+    framesIndex: 1,
+    globalNames: bytecode.GlobalNames,
+    globals:     globals,
+    maxDepth:    DEFAULT_MAX_DEPTH,
+    stack:       make([]Object, STACK_SIZE),
+  }
+}
+
+// Result returns the value of the program that was run, which, like
+// `evalProgram`, is the value of its last statement or of a top level
+// `return`.
+func (vm *VM) Result() Object {
+  return vm.result
+}
+
+// Run executes the bytecode. Errors raised by an instruction are located
+// at the source position that instruction was compiled from, and carry a
+// trace of the calls in progress.
+func (vm *VM) Run() error {
+  err := vm.run()
+
+  if err, ok := err.(*Error); ok {
+    if !err.Position.IsValid() {
+      err.Position = vm.position(vm.currentFrame())
+    }
+
+    if err.Trace == nil {
+      err.Trace = vm.trace()
+    }
+  }
+
+  return err
+}
+
+func (vm *VM) run() error {
+  var ip int
+  var ins Instructions
+  var op Opcode
+
+  for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+    vm.currentFrame().ip++
+
+    ip = vm.currentFrame().ip
+    ins = vm.currentFrame().Instructions()
+    op = Opcode(ins[ip])
+
+    switch op {
+    case OpConstant:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      constant := vm.constants[index]
+
+      // Integer literals too large for 64 bits follow the arithmetic, as
+      // they do when evaluated.
+      if integer, ok := constant.(*BigInt); ok {
+        constant = fitInteger(integer.Value, vm.arithmetic)
+      }
+
+      if err := vm.pushResult(constant); err != nil {
+        return err
+      }
+    case OpPop:
+      vm.result = vm.pop()
+    case OpTrue:
+      if err := vm.push(TRUE_LIT); err != nil {
+        return err
+      }
+    case OpFalse:
+      if err := vm.push(FALSE_LIT); err != nil {
+        return err
+      }
+    case OpNull:
+      if err := vm.push(NULL_LIT); err != nil {
+        return err
+      }
+    case OpAdd, OpSub, OpMul, OpDiv, OpModulo, OpPower, OpBitAnd, OpBitOr,
+      OpBitXor, OpShiftLeft, OpShiftRight, OpEqual, OpNotEqual, OpGreaterThan,
+      OpGreaterEqual, OpLessThan, OpLessEqual:
+      right := vm.pop()
+      left := vm.pop()
+
+      result := evalInfixExpression(
+        infixOperators[op],
+        left,
+        right,
+        vm.arithmetic,
+      )
+
+      if err := vm.pushResult(result); err != nil {
+        return err
+      }
+    case OpBang, OpBitNot, OpMinus:
+      right := vm.pop()
+
+      result := evalPrefixExpression(prefixOperators[op], right, vm.arithmetic)
+
+      if err := vm.pushResult(result); err != nil {
+        return err
+      }
+    case OpJump:
+      position := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip = position - 1
+    case OpJumpNotTruthy:
+      position := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      if !isTruthy(vm.pop()) {
+        vm.currentFrame().ip = position - 1
+      }
+    case OpJumpPassed:
+      index := int(ReadUint16(ins[ip+1:]))
+      position := int(ReadUint16(ins[ip+3:]))
+      vm.currentFrame().ip += 4
+
+      if index < vm.currentFrame().arguments {
+        vm.currentFrame().ip = position - 1
+      }
+    case OpSetGlobal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      vm.globals[index] = vm.pop()
+
+      // A top level `let` evaluates to null, just as it does in `Eval`.
+      vm.result = NULL_LIT
+    case OpGetGlobal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      global := vm.globals[index]
+
+      // A global is unset until its `let` runs, which it may never do if
+      // a branch skips it, an error interrupts it or it comes later.
+      if global == nil {
+        return newError("identifier not found: %s", vm.globalNames[index])
+      }
+
+      if err := vm.push(global); err != nil {
+        return err
+      }
+    case OpCheckGlobal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      if vm.globals[index] == nil {
+        return newUndeclaredError(vm.globalNames[index])
+      }
+    case OpSetLocal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      frame := vm.currentFrame()
+      slot := frame.basePointer + int(index)
+
+      if cell, ok := vm.stack[slot].(*Cell); ok {
+        cell.Value = vm.pop()
+      } else {
+        vm.stack[slot] = vm.pop()
+      }
+    case OpGetLocal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      frame := vm.currentFrame()
+      local := vm.stack[frame.basePointer+int(index)]
+
+      if cell, ok := local.(*Cell); ok {
+        local = cell.Value
+      }
+
+      // Like a global, a local is unset until its `let` runs.
+      if local == nil {
+        return newError(
+          "identifier not found: %s",
+          frame.closure.Fn.LocalNames[index],
+        )
+      }
+
+      if err := vm.push(local); err != nil {
+        return err
+      }
+    case OpCheckLocal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      frame := vm.currentFrame()
+      local := vm.stack[frame.basePointer+int(index)]
+
+      if cell, ok := local.(*Cell); ok {
+        local = cell.Value
+      }
+
+      if local == nil {
+        return newUndeclaredError(frame.closure.Fn.LocalNames[index])
+      }
+    case OpCaptureLocal:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      frame := vm.currentFrame()
+      slot := frame.basePointer + int(index)
+
+      // The local moves into a cell the first time a closure captures it,
+      // and the frame reads and writes it through the cell from then on.
+      cell, ok := vm.stack[slot].(*Cell)
+      if !ok {
+        cell = &Cell{Value: vm.stack[slot]}
+        vm.stack[slot] = cell
+      }
+
+      if err := vm.push(cell); err != nil {
+        return err
+      }
+    case OpGetFree:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      closure := vm.currentFrame().closure
+      cell := closure.Free[index].(*Cell)
+
+      if cell.Value == nil {
+        return newError(
+          "identifier not found: %s",
+          closure.Fn.FreeNames[index],
+        )
+      }
+
+      if err := vm.push(cell.Value); err != nil {
+        return err
+      }
+    case OpCheckFree:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      closure := vm.currentFrame().closure
+
+      if closure.Free[index].(*Cell).Value == nil {
+        return newUndeclaredError(closure.Fn.FreeNames[index])
+      }
+    case OpSetFree:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      vm.currentFrame().closure.Free[index].(*Cell).Value = vm.pop()
+    case OpCaptureFree:
+      index := ReadUint16(ins[ip+1:])
+      vm.currentFrame().ip += 2
+
+      if err := vm.push(vm.currentFrame().closure.Free[index]); err != nil {
+        return err
+      }
+    case OpCurrentClosure:
+      if err := vm.push(vm.currentFrame().closure); err != nil {
+        return err
+      }
+    case OpArray:
+      count := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      elements := make([]Object, count)
+      copy(elements, vm.stack[vm.sp-count:vm.sp])
+      vm.sp -= count
+
+      if err := vm.push(&Array{Elements: elements}); err != nil {
+        return err
+      }
+    case OpHash:
+      count := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      hash, err := vm.buildHash(vm.sp-count, vm.sp)
+      if err != nil {
+        return err
+      }
+      vm.sp -= count
+
+      if err := vm.push(hash); err != nil {
+        return err
+      }
+    case OpIndex:
+      index := vm.pop()
+      left := vm.pop()
+
+      if err := vm.pushResult(evalIndexExpression(left, index)); err != nil {
+        return err
+      }
+    case OpClosure:
+      index := ReadUint16(ins[ip+1:])
+      count := int(ReadUint16(ins[ip+3:]))
+      vm.currentFrame().ip += 4
+
+      if err := vm.pushClosure(int(index), count); err != nil {
+        return err
+      }
+    case OpCall:
+      count := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      if err := vm.call(count); err != nil {
+        return err
+      }
+    case OpMarkStack:
+      if err := vm.push(&Integer{Value: int64(vm.sp)}); err != nil {
+        return err
+      }
+    case OpUnwindStack:
+      vm.sp = int(vm.pop().(*Integer).Value)
+    case OpIterator:
+      elements, err := iterableElements(vm.pop())
+      if err != nil {
+        return err
+      }
+
+      if err := vm.push(&Iterator{Elements: elements}); err != nil {
+        return err
+      }
+    case OpNext:
+      position := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      element, ok := vm.pop().(*Iterator).Next()
+
+      if !ok {
+        vm.currentFrame().ip = position - 1
+      } else if err := vm.push(element); err != nil {
+        return err
+      }
+    case OpSpread:
+      if array := vm.stack[vm.sp-1]; array.Type() != ARRAY_OBJ {
+        return newError("spread argument must be ARRAY, got %s", array.Type())
+      }
+    case OpCallSpread:
+      count := int(ReadUint16(ins[ip+1:]))
+      vm.currentFrame().ip += 2
+
+      if err := vm.callSpread(count); err != nil {
+        return err
+      }
+    case OpReturnValue:
+      value := vm.pop()
+
+      if vm.framesIndex == 1 {
+        vm.result = value
+        return nil
+      }
+
+      frame := vm.popFrame()
+      vm.sp = frame.basePointer - 1
+
+      if err := vm.push(value); err != nil {
+        return err
+      }
+    case OpReturn:
+      frame := vm.popFrame()
+      vm.sp = frame.basePointer - 1
+
+      if err := vm.push(NULL_LIT); err != nil {
+        return err
+      }
+    default:
+      return fmt.Errorf("unknown opcode: %d", op)
+    }
+  }
+
+  return nil
+}
+
+func (vm *VM) buildHash(start, end int) (Object, error) {
+  pairs := make(map[HashKey]HashPair)
+
+  for i := start; i < end; i += 2 {
+    key := vm.stack[i]
+    value := vm.stack[i+1]
+
+    hashKey, ok := key.(Hashable)
+    if !ok {
+      return nil, newError("unusable as hash key: %s", key.Type())
+    }
+
+    pairs[hashKey.HashKey()] = HashPair{Key: key, Value: value}
+  }
+
+  return &Hash{Pairs: pairs}, nil
+}
+
+func (vm *VM) pushClosure(index int, count int) error {
+  function, ok := vm.constants[index].(*CompiledFunction)
+  if !ok {
+    return fmt.Errorf("not a function: %+v", vm.constants[index])
+  }
+
+  free := make([]Object, count)
+  copy(free, vm.stack[vm.sp-count:vm.sp])
+  vm.sp -= count
+
+  // A function captured by its own name arrives as a bare closure, and is
+  // put in a cell so that every free variable is read the same way.
+  for i, value := range free {
+    if _, ok := value.(*Cell); !ok {
+      free[i] = &Cell{Value: value}
+    }
+  }
+
+  return vm.push(&Closure{Fn: function, Free: free})
+}
+
+func (vm *VM) call(count int) error {
+  switch callee := vm.stack[vm.sp-1-count].(type) {
+  case *Closure:
+    return vm.callClosure(callee, count)
+  case *Builtin:
+    return vm.callBuiltin(callee, count)
+  default:
+    return newError("not a function: %s", callee.Type())
+  }
+}
+
+// callSpread calls a function whose arguments were compiled to count
+// arrays, concatenating them into the argument list.
+func (vm *VM) callSpread(count int) error {
+  arrays := make([]Object, count)
+  copy(arrays, vm.stack[vm.sp-count:vm.sp])
+  vm.sp -= count
+
+  args := 0
+
+  for _, array := range arrays {
+    for _, element := range array.(*Array).Elements {
+      if err := vm.push(element); err != nil {
+        return err
+      }
+      args++
+    }
+  }
+
+  return vm.call(args)
+}
+
+func (vm *VM) callClosure(closure *Closure, count int) error {
+  if !closure.Fn.Arity().Accepts(count) {
+    return newArityError(closure.Fn.Name, closure.Fn.Arity(), count)
+  }
+
+  if vm.framesIndex > vm.maxDepth {
+    return newRecursionError(closure.Fn.Name)
+  }
+
+  basePointer := vm.sp - count
+  top := basePointer + closure.Fn.NumLocals
+
+  // Arguments beyond the declared parameters are collected into the rest
+  // parameter, which is the local following them.
+  rest := []Object{}
+
+  if extra := count - closure.Fn.NumParameters; extra > 0 {
+    rest = make([]Object, extra)
+    copy(rest, vm.stack[vm.sp-extra:vm.sp])
+    vm.sp -= extra
+  }
+
+  vm.reserve(top)
+
+  for i := vm.sp; i < top; i++ {
+    vm.stack[i] = nil
+  }
+
+  if closure.Fn.Variadic {
+    vm.stack[basePointer+closure.Fn.NumParameters] = &Array{Elements: rest}
+  }
+
+  frame := NewFrame(closure, basePointer)
+  frame.arguments = count
+
+  vm.pushFrame(frame)
+  vm.sp = top
+
+  return nil
+}
+
+func (vm *VM) callBuiltin(builtin *Builtin, count int) error {
+  if !builtin.Arity.Accepts(count) {
+    return newArityError(builtin.Name, builtin.Arity, count)
+  }
+
+  args := make([]Object, count)
+  copy(args, vm.stack[vm.sp-count:vm.sp])
+
+  result := builtin.Fn(args...)
+  vm.sp = vm.sp - count - 1
+
+  if result == nil {
+    result = NULL_LIT
+  }
+
+  if err, ok := result.(*Error); ok && err.Trace == nil {
+    frame := StackFrame{
+      Function: builtin.Name,
+      Position: vm.position(vm.currentFrame()),
+    }
+    err.Trace = append([]StackFrame{frame}, vm.trace()...)
+  }
+
+  return vm.pushResult(result)
+}
+
+// pushResult pushes the result of an operation shared with the evaluator,
+// turning error values into errors that halt the machine.
+func (vm *VM) pushResult(result Object) error {
+  if err, ok := result.(*Error); ok {
+    return err
+  }
+
+  return vm.push(result)
+}
+
+func (vm *VM) push(obj Object) error {
+  vm.reserve(vm.sp + 1)
+
+  vm.stack[vm.sp] = obj
+  vm.sp++
+
+  return nil
+}
+
+// reserve grows the stack so that it holds at least size slots.
+func (vm *VM) reserve(size int) {
+  if size <= len(vm.stack) {
+    return
+  }
+
+  grown := make([]Object, 2*size)
+  copy(grown, vm.stack[:vm.sp])
+  vm.stack = grown
+}
+
+func (vm *VM) pop() Object {
+  obj := vm.stack[vm.sp-1]
+  vm.sp--
+  return obj
+}
+
+// position returns the source position of the instruction frame is
+// executing.
+func (vm *VM) position(frame *Frame) Position {
+  return frame.closure.Fn.SourceMap.Lookup(frame.ip)
+}
+
+// trace returns the calls in progress, innermost first, each located at
+// the call instruction in its caller.
+func (vm *VM) trace() []StackFrame {
+  var trace []StackFrame
+
+  for i := vm.framesIndex - 1; i > 0; i-- {
+    trace = append(trace, StackFrame{
+      Function: vm.frames[i].closure.Fn.Name,
+      Position: vm.position(vm.frames[i-1]),
+    })
+  }
+
+  return trace
+}
+
+func (vm *VM) currentFrame() *Frame {
+  return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+  if vm.framesIndex == len(vm.frames) {
+    vm.frames = append(vm.frames, f)
+  } else {
+    vm.frames[vm.framesIndex] = f
+  }
+
+  vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+  vm.framesIndex--
+  return vm.frames[vm.framesIndex]
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+  "testing"
+)
+
+func TestVMRunsEvalTests(t *testing.T) {
+  tests := map[string]func(*testing.T){
+    "ArrayIndexExpressions": TestArrayIndexExpressions,
+    "ArrayLiterals":         TestArrayLiterals,
+    "Assignment":            TestAssignment,
+    "BangOperator":          TestBangOperator,
+    "BuiltinFunctions":      TestBuiltinFunctions,
+    "BigIntegers":           TestBigIntegers,
+    "CheckedArithmetic":     TestCheckedArithmetic,
+    "Closures":              TestClosures,
+    "Constants":             TestConstants,
+    "DefaultParameters":     TestDefaultParameters,
+    "ErrorHandling":         TestErrorHandling,
+    "ErrorPositions":        TestErrorPositions,
+    "EvalBooleanExpression": TestEvalBooleanExpression,
+    "EvalFloatExpression":   TestEvalFloatExpression,
+    "EvalIntegerExpression": TestEvalIntegerExpression,
+    "EvalLetStatements":     TestEvalLetStatements,
+    "FunctionApplication":   TestFunctionApplication,
+    "FunctionObject":        TestFunctionObject,
+    "HashIndexExpressions":  TestHashIndexExpressions,
+    "HashLiterals":          TestHashLiterals,
+    "IfElseExpressions":     TestIfElseExpressions,
+    "LogicalOperators":      TestLogicalOperators,
+    "Loops":                 TestLoops,
+    "RecursionDepth":        TestRecursionDepth,
+    "RestParameters":        TestRestParameters,
+    "ReturnStatements":      TestReturnStatements,
+    "StackTraces":           TestStackTraces,
+    "StringComparison":      TestStringComparison,
+    "StringConcatenation":   TestStringConcatenation,
+    "StringLiteral":         TestStringLiteral,
+    "WrappingArithmetic":    TestWrappingArithmetic,
+  }
+
+  testEngine = ENGINE_VM
+  defer func() { testEngine = ENGINE_EVAL }()
+
+  for name, test := range tests {
+    t.Run(name, test)
+  }
+}
+
+func TestVMRecursiveFunctions(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected int64
+  }{
+    {
+      `
+let fibonacci = fn(x) {
+  if (x == 0) {
+    0
+  } else {
+    if (x == 1) {
+      1
+    } else {
+      fibonacci(x - 1) + fibonacci(x - 2);
+    }
+  }
+};
+
+fibonacci(15);
+`,
+      610,
+    },
+    {
+      `
+let wrapper = fn() {
+  let countDown = fn(x) {
+    if (x == 0) { return 0; }
+    countDown(x - 1);
+  };
+  countDown(5);
+};
+
+wrapper();
+`,
+      0,
+    },
+    {
+      `
+let newAdder = fn(a, b) {
+  fn(c) { fn(d) { a + b + c + d } };
+};
+
+newAdder(1, 2)(3)(4);
+`,
+      10,
+    },
+  }
+
+  for _, tt := range tests {
+    testIntegerObject(t, runVM(tt.input), tt.expected)
+  }
+}
+
+func TestVMRuntimeErrors(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected string
+  }{
+    {
+      "fn(a) { a }();",
+      "wrong number of arguments to anonymous function: want=1, got=0",
+    },
+    {
+      "let f = fn(x) { f(x + 1) }; f(0);",
+      "maximum recursion depth exceeded in `f`",
+    },
+  }
+
+  for _, tt := range tests {
+    evaluated := runVM(tt.input)
+
+    errObj, ok := evaluated.(*Error)
+    if !ok {
+      t.Errorf("no error object returned. got=%T(%+v)",
+        evaluated, evaluated)
+      continue
+    }
+
+    if errObj.Message != tt.expected {
+      t.Errorf("wrong error message. expected=%q, got=%q",
+        tt.expected, errObj.Message)
+    }
+  }
+}
+
+func TestVMLargeOperands(t *testing.T) {
+  arguments := make([]string, 300)
+  for i := range arguments {
+    arguments[i] = strconv.Itoa(i + 1)
+  }
+
+  // Identifiers cannot contain digits, so local i is named by spelling its
+  // digits as letters: v0 is "va", v267 is "vcgh".
+  digits := strings.NewReplacer(
+    "0", "a", "1", "b", "2", "c", "3", "d", "4", "e",
+    "5", "f", "6", "g", "7", "h", "8", "i", "9", "j",
+  )
+
+  locals := make([]string, 300)
+  for i := range locals {
+    locals[i] = fmt.Sprintf("let v%s = %d;", digits.Replace(strconv.Itoa(i)), i)
+  }
+
+  tests := []struct {
+    input    string
+    expected int64
+  }{
+    {
+      "let f = fn(...r) { len(r) }; f(" +
+        strings.Join(arguments, ", ") + ")",
+      300,
+    },
+    {
+      "let f = fn() { " + strings.Join(locals, " ") +
+        " vb + vcgh }; f()",
+      268,
+    },
+  }
+
+  for _, tt := range tests {
+    testIntegerObject(t, runVM(tt.input), tt.expected)
+  }
+}
+
+func TestVMLoopControlUnwindsStack(t *testing.T) {
+  tests := []string{
+    `
+let i = 0;
+while (i < 1000) {
+  i += 1;
+  let x = 1 + if (true) { continue; } else { 2 };
+}
+`,
+    "for (x in [1, 2, 3]) { [1, 2, if (x > 1) { break; } else { 3 }]; }",
+    `
+let f = fn() {
+  let i = 0;
+  while (i < 10) { i += 1; let x = [i, -if (true) { continue; } else { 1 }]; }
+  i
+};
+f();
+`,
+  }
+
+  for _, input := range tests {
+    compiler := NewCompiler()
+
+    if err := compiler.Compile(setupProgram(t, input)); err != nil {
+      t.Fatalf("compiler error: %s", err)
+    }
+
+    vm := NewVM(compiler.Bytecode())
+
+    if err := vm.Run(); err != nil {
+      t.Fatalf("vm error: %s", err)
+    }
+
+    if vm.sp != 0 {
+      t.Errorf("%q: stack not unwound. want=0, got=%d", input, vm.sp)
+    }
+  }
+}
+
+func TestMachineKeepsGlobalsBetweenRuns(t *testing.T) {
+  machine := NewMachine(Options{})
+
+  inputs := []string{
+    "let a = 1;",
+    "let add = fn(x) { x + a };",
+    "let a = 10;",
+  }
+
+  for _, input := range inputs {
+    testNullObject(t, machine.Run(NewParser(NewLexer(input)).Parse()))
+  }
+
+  program := NewParser(NewLexer("add(5)")).Parse()
+
+  testIntegerObject(t, machine.Run(program), 15)
+}
+
+func TestMachineReportsUnsetGlobals(t *testing.T) {
+  machine := NewMachine(Options{})
+
+  program := NewParser(NewLexer("let b = 1 + c;")).Parse()
+
+  if _, ok := machine.Run(program).(*Error); !ok {
+    t.Fatalf("expected an error defining b")
+  }
+
+  evaluated := machine.Run(NewParser(NewLexer("b")).Parse())
+
+  errObj, ok := evaluated.(*Error)
+  if !ok {
+    t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+  }
+
+  if errObj.Message != "identifier not found: b" {
+    t.Errorf("wrong error message. got=%q", errObj.Message)
+  }
+}
+
+func runVM(input string) Object {
+  return NewMachine(Options{}).Run(NewParser(NewLexer(input)).Parse())
+}